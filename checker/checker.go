@@ -64,7 +64,7 @@ type stateValue struct {
 
 func (val stateValue) String() string {
 	return fmt.Sprintf(
-		"{val=%s, ttlRemaining=%dms, writtenAtAgo=%dms, wasError=%t}, ",
+		"{val=%q, ttlRemaining=%dms, writtenAtAgo=%dms, wasError=%t}, ",
 		val.value,
 		time.Until(val.maybeExpiredBy).Milliseconds(),
 		time.Since(val.writtenAt).Milliseconds(),
@@ -210,14 +210,14 @@ func (cc *ConsistencyChecker) CheckReadCorrect(key, value string, wasFound bool,
 		if !found {
 			overwrittenVal, found := cc.overwrittenValues[key][value]
 			if found {
-				return fmt.Errorf("incorrect value %s; was overrwritten %dms ago", value, time.Since(overwrittenVal.overwrittenAt).Milliseconds())
+				return fmt.Errorf("incorrect value %q; was overrwritten %dms ago", value, time.Since(overwrittenVal.overwrittenAt).Milliseconds())
 			} else {
-				return fmt.Errorf("incorrect value %s; never written to key", val)
+				return fmt.Errorf("incorrect value %q; never written to key", val)
 			}
 		}
 		if *checkTtl && val.err == nil && val.definitelyExpiredBy.Before(startTime) {
 			timeDiff := startTime.Sub(val.maybeExpiredBy)
-			return fmt.Errorf("value %s was written, but expired at least %dms ago", value, timeDiff.Milliseconds())
+			return fmt.Errorf("value %q was written, but expired at least %dms ago", value, timeDiff.Milliseconds())
 		}
 	}
 	return nil
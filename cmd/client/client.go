@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"flag"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -19,13 +22,54 @@ import (
 //   - go run cmd/client/client.go --shardmap=shardmaps/test-1.json set abc 123 5000  # sets "abc" to "123" with TTL of 5s (5000ms)
 //   - go run cmd/client/client.go --shardmap=shardmaps/test-1.json get abc           # retrieves value for key "abc" (should be "123")
 //   - go run cmd/client/client.go --shardmap=shardmaps/test-1.json delete abc        # removes value at "abc"
+//   - go run cmd/client/client.go --shardmap=shardmaps/test-1.json scan ab           # lists all keys starting with "ab"
+//
+// Keys and values are taken (and printed) as text by default. For binary data pass
+// --encoding=hex or --encoding=base64, which uses the bytes-based API:
+//   - go run cmd/client/client.go --shardmap=shardmaps/test-1.json --encoding=hex set 00ff cafe 5000
 
 var (
 	shardMapFile = flag.String("shardmap", "", "Path to a JSON file which describes the shard map")
+	encoding     = flag.String("encoding", "text", "Encoding of keys and values on the command line: text, hex or base64")
+	readLevel    = flag.String("read-consistency", "", "Replicas a get waits for: one (default), quorum or all")
+	writeLevel   = flag.String("write-consistency", "", "Replicas a set or delete waits for: one, quorum or all (default)")
+	readRepair   = flag.Bool("read-repair", false, "Write the newest value back to stale replicas found by a get")
+	primary      = flag.Bool("primary-backup", false, "Send writes only to the primary of each shard (servers must run with --primary-backup)")
+	raft         = flag.Bool("raft", false, "Send every call to the Raft leader of its shard (servers must run with --raft)")
+	chain        = flag.Bool("chain-replication", false, "Send writes to the head of each shard's chain and reads to its tail (servers must run with --chain-replication)")
 )
 
+func decodeArg(arg string) []byte {
+	var decoded []byte
+	var err error
+	switch *encoding {
+	case "text":
+		return []byte(arg)
+	case "hex":
+		decoded, err = hex.DecodeString(arg)
+	case "base64":
+		decoded, err = base64.StdEncoding.DecodeString(arg)
+	default:
+		err = fmt.Errorf("unknown encoding: %q", *encoding)
+	}
+	if err != nil {
+		logrus.Fatalf("invalid argument %q: %q", arg, err)
+	}
+	return decoded
+}
+
+func encodeOutput(data []byte) string {
+	switch *encoding {
+	case "hex":
+		return hex.EncodeToString(data)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	return string(data)
+}
+
 func usage() {
-	logrus.Fatal("Usage: client.go [get|set|delete] key [value] [ttl]")
+	logrus.Fatal("Usage: client.go [get|set|delete|scan] key [value] [ttl]")
 }
 
 func main() {
@@ -44,7 +88,14 @@ func main() {
 
 	clientPool := kv.MakeClientPool(&fileSm.ShardMap)
 
-	client := kv.MakeKv(&fileSm.ShardMap, &clientPool)
+	options := kv.KvOptions{ReadRepair: *readRepair, PrimaryBackup: *primary, Raft: *raft, ChainReplication: *chain}
+	if options.ReadConsistency, err = kv.ParseConsistencyLevel(*readLevel); err != nil {
+		logrus.Fatal(err)
+	}
+	if options.WriteConsistency, err = kv.ParseConsistencyLevel(*writeLevel); err != nil {
+		logrus.Fatal(err)
+	}
+	client := kv.MakeKvWithOptions(&fileSm.ShardMap, &clientPool, options)
 
 	subcommand := args[0]
 	key := args[1]
@@ -52,13 +103,13 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	switch subcommand {
 	case "get":
-		value, isSet, err := client.Get(ctx, key)
+		value, isSet, err := client.GetBytes(ctx, decodeArg(key))
 		if err != nil {
 			logrus.WithField("key", key).Errorf("error getting value for key: %q", err)
 		} else if !isSet {
 			logrus.WithField("key", key).Info("no value set for key")
 		} else {
-			println(value)
+			println(encodeOutput(value))
 		}
 	case "set":
 		if len(args) < 4 {
@@ -69,15 +120,25 @@ func main() {
 		if err != nil {
 			logrus.Fatalf("expected int value for ttlMs: %q", err)
 		}
-		err = client.Set(ctx, key, value, time.Duration(ttlMs)*time.Millisecond)
+		err = client.SetBytes(ctx, decodeArg(key), decodeArg(value), time.Duration(ttlMs)*time.Millisecond)
 		if err != nil {
 			logrus.WithField("key", key).Errorf("error setting value: %q", err)
 		}
 	case "delete":
-		err := client.Delete(ctx, key)
+		err := client.DeleteBytes(ctx, decodeArg(key))
 		if err != nil {
 			logrus.WithField("key", key).Errorf("error deleting value for key: %q", err)
 		}
+	case "scan":
+		// key is the prefix
+		it := client.NewScanIterator(ctx, string(decodeArg(key)), "", 0)
+		for it.Next() {
+			entry := it.Entry()
+			println(encodeOutput([]byte(entry.Key)), encodeOutput([]byte(entry.Value)))
+		}
+		if err := it.Err(); err != nil {
+			logrus.WithField("prefix", key).Errorf("error scanning keys: %q", err)
+		}
 	default:
 		usage()
 	}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -23,8 +24,21 @@ import (
 // a bunch of "nodes" as separate processes locally on your machine.
 
 var (
-	shardMapFile = flag.String("shardmap", "", "Path to a JSON file which describes the shard map")
-	nodeName     = flag.String("node", "", "Name of the node (must match in shard map file)")
+	shardMapFile     = flag.String("shardmap", "", "Path to a JSON file which describes the shard map")
+	nodeName         = flag.String("node", "", "Name of the node (must match in shard map file)")
+	dataDir          = flag.String("data-dir", "", "If set, persist data to this directory and recover it on restart")
+	snapshotInterval = flag.Duration("snapshot-interval", time.Minute, "How often shards are snapshotted to --data-dir")
+	memoryBudget     = flag.Int64("memory-budget", 0, "If > 0, evict entries to keep the bytes of keys+values stored under this budget")
+	evictionPolicy   = flag.String("eviction-policy", kv.EvictLRU, "Which entries to evict first when over --memory-budget: lru, lfu or ttl")
+	storageEngine    = flag.String("storage-engine", kv.StorageMap, "How shards are stored in memory: map (hash map) or btree (ordered, faster scans)")
+	antiEntropy      = flag.Duration("anti-entropy-interval", 0, "If > 0, how often hosted shards are compared with their other replicas and repaired (ignored with --raft, --primary-backup and --chain-replication)")
+	antiEntropyRate  = flag.Int("anti-entropy-keys-per-second", 1000, "Maximum number of keys anti-entropy repairs per second")
+	primaryBackup    = flag.Bool("primary-backup", false, "Only accept client writes for shards this node is the primary of, and replicate them to the backups")
+	raft             = flag.Bool("raft", false, "Replicate each shard with a Raft group of the nodes hosting it, making operations linearizable (requires --data-dir, for the groups' logs)")
+	chain            = flag.Bool("chain-replication", false, "Replicate each shard down the chain of its nodes: writes enter at the first node, reads are served by the last")
+	copyParallelism  = flag.Int("shard-copy-parallelism", 4, "Maximum number of newly assigned shards copied from other nodes at once")
+	copyTimeout      = flag.Duration("shard-copy-timeout", time.Minute, "How long copying a shard from one node may take before another node is tried")
+	adminAddr        = flag.String("admin-addr", "", "If set, serve the operator-only KvAdmin service (shard copy status, cancel and retry) on this address, e.g. localhost:9000; keep it unreachable for clients")
 )
 
 func main() {
@@ -55,10 +69,50 @@ func main() {
 
 	clientPool := kv.MakeClientPool(&fileSm.ShardMap)
 
-	proto.RegisterKvServer(
-		server,
-		kv.MakeKvServer(*nodeName, &fileSm.ShardMap, &clientPool),
+	antiEntropyInterval := *antiEntropy
+	if *raft || *primaryBackup || *chain {
+		// these order writes to the replicas themselves, which repairs would
+		// go around
+		antiEntropyInterval = 0
+	}
+	kvServer, err := kv.MakeKvServerWithOptions(
+		*nodeName,
+		&fileSm.ShardMap,
+		&clientPool,
+		kv.KvServerOptions{
+			DataDir:                  *dataDir,
+			SnapshotInterval:         *snapshotInterval,
+			MemoryBudgetBytes:        *memoryBudget,
+			EvictionPolicy:           *evictionPolicy,
+			StorageEngine:            *storageEngine,
+			AntiEntropyInterval:      antiEntropyInterval,
+			AntiEntropyKeysPerSecond: *antiEntropyRate,
+			PrimaryBackup:            *primaryBackup,
+			Raft:                     *raft,
+			ChainReplication:         *chain,
+			ShardCopyParallelism:     *copyParallelism,
+			ShardCopyTimeout:         *copyTimeout,
+		},
 	)
+	if err != nil {
+		logrus.Fatalf("failed to start server: %v", err)
+	}
+	proto.RegisterKvServer(server, kvServer)
+	if *adminAddr != "" {
+		// on its own listener, so it is not exposed with the Kv service
+		adminLis, err := net.Listen("tcp", *adminAddr)
+		if err != nil {
+			logrus.Fatalf("failed to listen for admin calls: %v", err)
+		}
+		adminServer := grpc.NewServer()
+		proto.RegisterKvAdminServer(adminServer, kv.MakeKvAdmin(kvServer))
+		logrus.Infof("admin server listening at %v", adminLis.Addr())
+		go func() {
+			if err := adminServer.Serve(adminLis); err != nil {
+				logrus.Fatalf("failed to serve admin calls: %v", err)
+			}
+		}()
+	}
 	logrus.Infof("server listening at %v", lis.Addr())
 	if err := server.Serve(lis); err != nil {
 		logrus.Fatalf("failed to serve: %v", err)
@@ -55,6 +55,7 @@ var (
 	maxPendingRequests = flag.Int("max-pending", 100, "Maximum number of in-flight requests before the stress tester slows down")
 	numKeys            = flag.Int("num-keys", 1000, "Number of unique keys to stress")
 	ttl                = flag.Duration("ttl", 2*time.Second, "TTL of values to set on keys")
+	binaryValues       = flag.Bool("binary-values", false, "Set random binary values through the bytes API instead of text")
 )
 
 /*
@@ -89,6 +90,12 @@ func randomString(rng *rand.Rand, length int) string {
 	return out.String()
 }
 
+func randomBytes(rng *rand.Rand, length int) string {
+	out := make([]byte, length)
+	rng.Read(out)
+	return string(out)
+}
+
 func randomKeys(n, length int) []string {
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	out := make([]string, 0)
@@ -132,6 +139,9 @@ func (st *stressTester) stressLoop(
 		st.wg.Add(1)
 		key := st.keys[rng.Int()%len(st.keys)]
 		value := randomString(rand.New(rand.NewSource(time.Now().UnixMicro())), 32)
+		if *binaryValues {
+			value = randomBytes(rand.New(rand.NewSource(time.Now().UnixMicro())), 32)
+		}
 		go func() {
 			ctx, cancel := context.WithTimeout(st.ctx, *timeout)
 			sendReqFn(ctx, key, value)
@@ -148,7 +158,16 @@ func (st *stressTester) stressGets() {
 		startTime := time.Now()
 
 		initialVersion, writesPending := st.cc.BeginRead(key)
-		val, wasFound, err := st.kv.Get(ctx, key)
+		var val string
+		var wasFound bool
+		var err error
+		if *binaryValues {
+			var valBytes []byte
+			valBytes, wasFound, err = st.kv.GetBytes(ctx, []byte(key))
+			val = string(valBytes)
+		} else {
+			val, wasFound, err = st.kv.Get(ctx, key)
+		}
 		latency := time.Since(startTime)
 
 		atomic.AddUint64(&st.gets, 1)
@@ -175,7 +194,12 @@ func (st *stressTester) stressSets() {
 		startTime := time.Now()
 		initialVersion := st.cc.BeginWrite(key)
 
-		err := st.kv.Set(ctx, key, value, *ttl)
+		var err error
+		if *binaryValues {
+			err = st.kv.SetBytes(ctx, []byte(key), []byte(value), *ttl)
+		} else {
+			err = st.kv.Set(ctx, key, value, *ttl)
+		}
 
 		latency := time.Since(startTime)
 		endTime := time.Now()
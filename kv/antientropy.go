@@ -0,0 +1,323 @@
+package kv
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Anti-entropy: a background process which finds and fixes replicas of a shard
+ * which diverged, e.g. after partially failed writes whose hints were lost.
+ *
+ * Every AntiEntropyInterval, the server compares each shard it hosts with one of
+ * the shard's other replicas (taking turns between them). It walks both Merkle
+ * trees (see merkle.go) from the root, only descending into nodes whose hashes
+ * differ, then fetches the entries of the differing leaves. Each key is resolved
+ * by last-writer-wins on the write timestamp: newer values are stored locally,
+ * and ours are sent over with ReplicaWrite where the peer is behind.
+ *
+ * Keys synced in either direction are rate-limited by AntiEntropyKeysPerSecond,
+ * so a badly diverged replica is repaired gradually rather than all at once.
+ *
+ * Deletes leave tombstones (see entry.deleted), which are synced like values,
+ * so a replica which missed a delete gets it rather than bringing the key back.
+ * This only holds while the tombstone is kept: a replica which stays behind for
+ * longer than KvServerOptions.TombstoneGracePeriod can still resurrect the key.
+ */
+
+const defaultAntiEntropyKeysPerSecond = 1000
+
+func (server *KvServerImpl) GetMerkleHashes(
+	ctx context.Context,
+	request *proto.MerkleHashesRequest,
+) (*proto.MerkleHashesResponse, error) {
+	shard := int(request.Shard)
+	if !server.isShardHosted(shard) {
+		return nil, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	tree, err := server.shardMerkleTree(shard)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]uint64, len(request.Nodes))
+	for i, node := range request.Nodes {
+		if node < merkleRoot || int(node) >= len(tree) {
+			return nil, status.Errorf(codes.InvalidArgument, "no Merkle tree node %d", node)
+		}
+		hashes[i] = tree[node]
+	}
+	return &proto.MerkleHashesResponse{Hashes: hashes}, nil
+}
+
+/*
+ * Merkle trees of the shards peers compare with us. A comparison asks for one
+ * level of the tree at a time, so the tree is kept until the shard changes
+ * rather than rebuilt from the whole shard for every level.
+ */
+type merkleTreeCache struct {
+	mutex sync.Mutex
+	trees map[int]cachedMerkleTree
+}
+
+type cachedMerkleTree struct {
+	// server.shardChanges when the tree was built
+	changes uint64
+	tree    *merkleTree
+}
+
+/*
+ * The Merkle tree of `shard`, built again only if the shard changed since the
+ * cached one was. Entries which expired since are left in until Clean drops
+ * them, which only delays their removal from the tree by a tick.
+ */
+func (server *KvServerImpl) shardMerkleTree(shard int) (*merkleTree, error) {
+	server.shardLock.RLock()
+	if server.data == nil {
+		server.shardLock.RUnlock()
+		return nil, status.Error(codes.Unavailable, "Server shutting down")
+	}
+	server.locks[shard-1].RLock()
+	changes := server.shardChanges[shard-1]
+	server.locks[shard-1].RUnlock()
+	server.shardLock.RUnlock()
+
+	cache := &server.merkleTrees
+	cache.mutex.Lock()
+	cached, exists := cache.trees[shard]
+	cache.mutex.Unlock()
+	if exists && cached.changes == changes {
+		server.stats.Add("merkle_tree_cache_hits", 1)
+		return cached.tree, nil
+	}
+
+	// the snapshot may include changes made since we read the count, in which
+	// case the next call builds the tree again
+	snapshot, err := server.snapshotShard(shard)
+	if err != nil {
+		return nil, err
+	}
+	tree := buildMerkleTree(snapshot, uint64(time.Now().UnixMilli()))
+	cache.mutex.Lock()
+	if cache.trees == nil {
+		cache.trees = make(map[int]cachedMerkleTree)
+	}
+	cache.trees[shard] = cachedMerkleTree{changes: changes, tree: tree}
+	cache.mutex.Unlock()
+	return tree, nil
+}
+
+func (server *KvServerImpl) GetMerkleLeaves(
+	ctx context.Context,
+	request *proto.MerkleLeavesRequest,
+) (*proto.MerkleLeavesResponse, error) {
+	shard := int(request.Shard)
+	if !server.isShardHosted(shard) {
+		return nil, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	leaves := make(map[uint32]bool, len(request.Leaves))
+	for _, leaf := range request.Leaves {
+		if !merkleIsLeaf(leaf) || int(leaf) >= len(merkleTree{}) {
+			return nil, status.Errorf(codes.InvalidArgument, "no Merkle tree leaf %d", leaf)
+		}
+		leaves[leaf] = true
+	}
+	snapshot, err := server.snapshotShard(shard)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.MerkleLeavesResponse{Values: leafValues(snapshot, leaves)}, nil
+}
+
+// Entries of the snapshot which fall in `leaves` and have not expired, tombstones included
+func leafValues(snapshot shardSnapshot, leaves map[uint32]bool) []*proto.GetShardValue {
+	now := uint64(time.Now().UnixMilli())
+	values := make([]*proto.GetShardValue, 0)
+	snapshot.iterate(func(e *entry) bool {
+		if e.ttl >= now && leaves[merkleLeaf(e.key)] {
+			values = append(values, shardValue(e, now))
+		}
+		return true
+	})
+	return values
+}
+
+func (server *KvServerImpl) antiEntropyLoop(interval time.Duration, keysPerSecond int) {
+	if keysPerSecond <= 0 {
+		keysPerSecond = defaultAntiEntropyKeysPerSecond
+	}
+	limiter := rate.NewLimiter(rate.Limit(keysPerSecond), keysPerSecond)
+	// cancelled on shutdown, so a round waiting on the limiter stops right away
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-server.shutdown
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for round := 0; ; round++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if ctx.Err() != nil {
+			// both were ready: shutting down takes precedence
+			return
+		}
+		server.antiEntropyRound(ctx, limiter, round)
+	}
+}
+
+func (server *KvServerImpl) antiEntropyRound(ctx context.Context, limiter *rate.Limiter, round int) {
+	server.shardLock.RLock()
+	shards := make([]int, 0, len(server.hostedShards))
+	for shard, hosted := range server.hostedShards {
+		if hosted {
+			shards = append(shards, shard)
+		}
+	}
+	server.shardLock.RUnlock()
+	slices.Sort(shards)
+
+	for _, shard := range shards {
+		if ctx.Err() != nil {
+			return
+		}
+		peers := slices.DeleteFunc(slices.Clone(server.shardMap.NodesForShard(shard)), func(node string) bool {
+			return node == server.nodeName
+		})
+		if len(peers) == 0 {
+			continue
+		}
+		peer := peers[round%len(peers)]
+		if err := server.antiEntropyShard(ctx, limiter, shard, peer); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			server.stats.Add("anti_entropy_errors", 1)
+			logrus.WithFields(logrus.Fields{"node": server.nodeName, "shard": shard, "peer": peer}).Debugf("anti-entropy failed: %q", err)
+		}
+	}
+	server.stats.Add("anti_entropy_rounds", 1)
+}
+
+/*
+ * Compares our copy of `shard` with the one on `peer`, and syncs the keys in the
+ * leaves where they differ.
+ */
+func (server *KvServerImpl) antiEntropyShard(ctx context.Context, limiter *rate.Limiter, shard int, peer string) error {
+	if !server.isShardHosted(shard) {
+		return nil
+	}
+	client, err := server.clientPool.GetClient(peer)
+	if err != nil {
+		return err
+	}
+	snapshot, err := server.snapshotShard(shard)
+	if err != nil {
+		return err
+	}
+	tree := buildMerkleTree(snapshot, uint64(time.Now().UnixMilli()))
+	server.stats.Add("anti_entropy_shards_compared", 1)
+
+	// walk down the levels of the tree, keeping the nodes which differ
+	nodes := []uint32{merkleRoot}
+	for {
+		response, err := client.GetMerkleHashes(ctx, &proto.MerkleHashesRequest{Shard: int32(shard), Nodes: nodes})
+		if err != nil {
+			return err
+		}
+		if len(response.Hashes) != len(nodes) {
+			return status.Error(codes.Internal, "peer returned the wrong number of hashes")
+		}
+		differing := make([]uint32, 0)
+		for i, node := range nodes {
+			if tree[node] != response.Hashes[i] {
+				differing = append(differing, node)
+			}
+		}
+		if len(differing) == 0 {
+			return nil
+		}
+		if merkleIsLeaf(differing[0]) {
+			nodes = differing
+			break
+		}
+		nodes = make([]uint32, 0, 2*len(differing))
+		for _, node := range differing {
+			nodes = append(nodes, 2*node, 2*node+1)
+		}
+	}
+	server.stats.Add("anti_entropy_shards_differed", 1)
+
+	response, err := client.GetMerkleLeaves(ctx, &proto.MerkleLeavesRequest{Shard: int32(shard), Leaves: nodes})
+	if err != nil {
+		return err
+	}
+	leaves := make(map[uint32]bool, len(nodes))
+	for _, leaf := range nodes {
+		leaves[leaf] = true
+	}
+	ours := make(map[string]*proto.GetShardValue)
+	for _, value := range leafValues(snapshot, leaves) {
+		ours[string(value.Key)] = value
+	}
+
+	for _, theirs := range response.Values {
+		key := string(theirs.Key)
+		if GetShardForKey(key, server.shardMap.NumShards()) != shard {
+			continue
+		}
+		mine, exists := ours[key]
+		delete(ours, key)
+		if exists && !newerValue(theirs.Timestamp, theirs.Version, mine.Timestamp, mine.Version) {
+			if newerValue(mine.Timestamp, mine.Version, theirs.Timestamp, theirs.Version) {
+				if err := server.pushAntiEntropy(ctx, limiter, client, mine); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		applied, err := server.storeReplicaValue(shard, theirs)
+		if err != nil {
+			return err
+		}
+		if applied {
+			server.stats.Add("anti_entropy_keys_pulled", 1)
+		}
+	}
+	// what is left, the peer does not have at all
+	for _, mine := range ours {
+		if err := server.pushAntiEntropy(ctx, limiter, client, mine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server *KvServerImpl) pushAntiEntropy(ctx context.Context, limiter *rate.Limiter, client proto.KvClient, value *proto.GetShardValue) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	response, err := client.ReplicaWrite(ctx, &proto.ReplicaWriteRequest{Value: value})
+	if err != nil {
+		return err
+	}
+	if response.Applied {
+		server.stats.Add("anti_entropy_keys_pushed", 1)
+	}
+	return nil
+}
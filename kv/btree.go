@@ -0,0 +1,294 @@
+package kv
+
+import (
+	"slices"
+	"sort"
+)
+
+/*
+ * In-memory B-tree of entries ordered by key, used by the btree storage engine.
+ *
+ * Every node but the root holds between btreeMinItems and btreeMaxItems entries,
+ * and inner nodes have one more child than entries. Insertion splits full nodes
+ * on the way down and removal tops up minimal nodes on the way down (borrowing
+ * from a sibling or merging with one), so both are a single pass from the root.
+ *
+ * The tree is copy-on-write: snapshot returns a read-only view sharing every node
+ * with the tree, in O(1). Each node records which tree generation (btreeCow) owns
+ * it, and the tree only modifies nodes it owns, copying any other node on its way
+ * down before changing it. Taking a snapshot starts a new generation, so nodes
+ * the snapshot can see are never modified again.
+ */
+
+const (
+	btreeDegree   = 32
+	btreeMaxItems = 2*btreeDegree - 1
+	btreeMinItems = btreeDegree - 1
+)
+
+// Not zero-sized, so every generation has a distinct address
+type btreeCow struct {
+	_ byte
+}
+
+type btreeNode struct {
+	items []*entry
+	// empty for leaves
+	children []*btreeNode
+	cow      *btreeCow
+}
+
+type btree struct {
+	root   *btreeNode
+	length int
+	// generation of the nodes this tree may modify in place
+	cow *btreeCow
+}
+
+func (n *btreeNode) leaf() bool {
+	return len(n.children) == 0
+}
+
+// Index of the first item with a key >= key, and whether that item has this key
+func (n *btreeNode) find(key string) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool { return n.items[i].key >= key })
+	return i, i < len(n.items) && n.items[i].key == key
+}
+
+// n itself if generation cow owns it, otherwise a copy owned by cow
+func (n *btreeNode) mutableFor(cow *btreeCow) *btreeNode {
+	if n.cow == cow {
+		return n
+	}
+	return &btreeNode{items: slices.Clone(n.items), children: slices.Clone(n.children), cow: cow}
+}
+
+// Child i, made modifiable by n's generation
+func (n *btreeNode) mutableChild(i int) *btreeNode {
+	child := n.children[i].mutableFor(n.cow)
+	n.children[i] = child
+	return child
+}
+
+/*
+ * A read-only view of the tree as it is now. Later changes to the tree are not
+ * visible through it, and it must not be modified.
+ */
+func (t *btree) snapshot() *btree {
+	snapshot := &btree{root: t.root, length: t.length, cow: t.cow}
+	t.cow = &btreeCow{}
+	return snapshot
+}
+
+func (t *btree) get(key string) *entry {
+	for n := t.root; n != nil; {
+		i, found := n.find(key)
+		if found {
+			return n.items[i]
+		}
+		if n.leaf() {
+			return nil
+		}
+		n = n.children[i]
+	}
+	return nil
+}
+
+// Adds e, returning the entry it replaced (if any had the same key)
+func (t *btree) insert(e *entry) *entry {
+	if t.root == nil {
+		t.root = &btreeNode{items: []*entry{e}, cow: t.cow}
+		t.length++
+		return nil
+	}
+	t.root = t.root.mutableFor(t.cow)
+	if len(t.root.items) >= btreeMaxItems {
+		oldRoot := t.root
+		t.root = &btreeNode{children: []*btreeNode{oldRoot}, cow: t.cow}
+		t.root.splitChild(0)
+	}
+	replaced := t.root.insert(e)
+	if replaced == nil {
+		t.length++
+	}
+	return replaced
+}
+
+// NOTE: n must not be full, and must be owned by the tree's generation
+func (n *btreeNode) insert(e *entry) *entry {
+	i, found := n.find(e.key)
+	if found {
+		replaced := n.items[i]
+		n.items[i] = e
+		return replaced
+	}
+	if n.leaf() {
+		n.items = insertAt(n.items, i, e)
+		return nil
+	}
+	if len(n.children[i].items) >= btreeMaxItems {
+		n.splitChild(i)
+		switch {
+		case e.key > n.items[i].key:
+			i++
+		case e.key == n.items[i].key:
+			replaced := n.items[i]
+			n.items[i] = e
+			return replaced
+		}
+	}
+	return n.mutableChild(i).insert(e)
+}
+
+// Splits the full child i in two around its middle item, which moves up into n
+func (n *btreeNode) splitChild(i int) {
+	child := n.mutableChild(i)
+	middle := child.items[btreeMinItems]
+	right := &btreeNode{items: append([]*entry(nil), child.items[btreeMinItems+1:]...), cow: n.cow}
+	clear(child.items[btreeMinItems:])
+	child.items = child.items[:btreeMinItems]
+	if !child.leaf() {
+		right.children = append([]*btreeNode(nil), child.children[btreeMinItems+1:]...)
+		clear(child.children[btreeMinItems+1:])
+		child.children = child.children[:btreeMinItems+1]
+	}
+	n.items = insertAt(n.items, i, middle)
+	n.children = insertAt(n.children, i+1, right)
+}
+
+// Removes the entry for key, returning it (nil if there was none)
+func (t *btree) remove(key string) *entry {
+	if t.root == nil {
+		return nil
+	}
+	t.root = t.root.mutableFor(t.cow)
+	removed := t.root.remove(key, false)
+	if len(t.root.items) == 0 {
+		if t.root.leaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+	if removed != nil {
+		t.length--
+	}
+	return removed
+}
+
+/*
+ * Removes the entry for key from the subtree, or with `last` set, its last entry.
+ *
+ * NOTE: n must have more than btreeMinItems items, unless it is the root, and
+ * must be owned by the tree's generation
+ */
+func (n *btreeNode) remove(key string, last bool) *entry {
+	var i int
+	var found bool
+	if last {
+		i = len(n.items)
+		if n.leaf() {
+			removed := n.items[i-1]
+			n.items = removeAt(n.items, i-1)
+			return removed
+		}
+	} else {
+		i, found = n.find(key)
+		if n.leaf() {
+			if !found {
+				return nil
+			}
+			removed := n.items[i]
+			n.items = removeAt(n.items, i)
+			return removed
+		}
+	}
+
+	if len(n.children[i].items) <= btreeMinItems {
+		// make room in the child first, which may move our items around
+		n.growChild(i)
+		return n.remove(key, last)
+	}
+	if found {
+		// replace the item with its predecessor, the last item of its left subtree
+		removed := n.items[i]
+		n.items[i] = n.mutableChild(i).remove("", true)
+		return removed
+	}
+	return n.mutableChild(i).remove(key, last)
+}
+
+// Gives child i more than btreeMinItems items, from a sibling or by merging with one
+func (n *btreeNode) growChild(i int) {
+	switch {
+	case i > 0 && len(n.children[i-1].items) > btreeMinItems:
+		child, left := n.mutableChild(i), n.mutableChild(i-1)
+		child.items = insertAt(child.items, 0, n.items[i-1])
+		n.items[i-1] = left.items[len(left.items)-1]
+		left.items = removeAt(left.items, len(left.items)-1)
+		if !left.leaf() {
+			child.children = insertAt(child.children, 0, left.children[len(left.children)-1])
+			left.children = removeAt(left.children, len(left.children)-1)
+		}
+	case i < len(n.items) && len(n.children[i+1].items) > btreeMinItems:
+		child, right := n.mutableChild(i), n.mutableChild(i+1)
+		child.items = append(child.items, n.items[i])
+		n.items[i] = right.items[0]
+		right.items = removeAt(right.items, 0)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = removeAt(right.children, 0)
+		}
+	default:
+		if i >= len(n.items) {
+			// the last child merges with its left sibling instead
+			i--
+		}
+		// right is dropped from the tree, so it is only read
+		child, right := n.mutableChild(i), n.children[i+1]
+		child.items = append(child.items, n.items[i])
+		child.items = append(child.items, right.items...)
+		child.children = append(child.children, right.children...)
+		n.items = removeAt(n.items, i)
+		n.children = removeAt(n.children, i+1)
+	}
+}
+
+// Calls fn on the entries with keys >= from, in order, until it returns false
+func (t *btree) ascend(from string, fn func(e *entry) bool) {
+	if t.root != nil {
+		t.root.ascend(from, fn)
+	}
+}
+
+func (n *btreeNode) ascend(from string, fn func(e *entry) bool) bool {
+	// children before i only hold keys < from
+	i, _ := n.find(from)
+	for ; i < len(n.items); i++ {
+		if !n.leaf() && !n.children[i].ascend(from, fn) {
+			return false
+		}
+		if !fn(n.items[i]) {
+			return false
+		}
+	}
+	if !n.leaf() {
+		return n.children[len(n.items)].ascend(from, fn)
+	}
+	return true
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func removeAt[T any](s []T, i int) []T {
+	var zero T
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = zero
+	return s[:len(s)-1]
+}
@@ -0,0 +1,166 @@
+package kv
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Chain replication (KvServerOptions.ChainReplication). The nodes listed for a
+ * shard in the ShardMap form its chain, in order: clients send writes to the
+ * head (the first node), and reads to the tail (the last one). Other nodes
+ * reject them with a FailedPrecondition error carrying a NotPrimary detail which
+ * names the head or tail (see KvOptions.ChainReplication).
+ *
+ * The head applies a write, then sends the resulting state of the key to its
+ * successor (ChainWrite), which applies it and passes it on in turn. The call
+ * returns once the tail applied the write, and only then is the client acked,
+ * so the tail only ever serves writes every replica has. As in primary-backup
+ * mode (see primary.go), each node holds the key's lock until its successor
+ * answered, which orders writes to a key the same way on the whole chain.
+ *
+ * Reconfiguration: nodes only accept writes from their predecessor in their own
+ * ShardMap. A node whose write to its successor fails (because the successor
+ * was removed from the chain, or rejects us after the ShardMap changed our
+ * predecessor) looks up its successor again and resends the write, until it
+ * goes through or chainWriteTimeout passes. This recovers writes in flight when
+ * a node is removed: the predecessor of a removed node resends them to the next
+ * one, a new tail no longer has a successor and completes them, and the next
+ * node of a removed head keeps passing on those which reached it. Writes carry
+ * the whole state of the key, so applying one twice is harmless.
+ */
+
+const (
+	// how long a node keeps trying to pass a write down the chain
+	chainWriteTimeout = 2 * time.Second
+	// pause before resending a write which failed
+	chainRetryDelay = 20 * time.Millisecond
+)
+
+// Predecessor and successor of this node in the chain of `shard` ("" for none),
+// and whether it is in the chain
+func (server *KvServerImpl) chainNeighbors(shard int) (string, string, bool) {
+	nodes := server.shardMap.NodesForShard(shard)
+	i := slices.Index(nodes, server.nodeName)
+	if i < 0 {
+		return "", "", false
+	}
+	predecessor, successor := "", ""
+	if i > 0 {
+		predecessor = nodes[i-1]
+	}
+	if i < len(nodes)-1 {
+		successor = nodes[i+1]
+	}
+	return predecessor, successor, true
+}
+
+/*
+ * In chain replication mode, fails client reads of `shard` unless we are the
+ * tail of its chain.
+ */
+func (server *KvServerImpl) checkChainTail(shard int) error {
+	if !server.chainReplication {
+		return nil
+	}
+	nodes := server.shardMap.NodesForShard(shard)
+	tail := ""
+	if len(nodes) > 0 {
+		tail = nodes[len(nodes)-1]
+	}
+	if tail == server.nodeName {
+		return nil
+	}
+	server.stats.Add("chain_read_redirects", 1)
+	s := status.Newf(codes.FailedPrecondition, "not the tail of the chain of shard %d (tail is %q)", shard, tail)
+	withDetails, err := s.WithDetails(&proto.NotPrimary{Shard: int32(shard), Primary: tail})
+	if err != nil {
+		return s.Err()
+	}
+	return withDetails.Err()
+}
+
+/*
+ * Sends the current state of `key` to our successor in the chain of `shard`,
+ * returning once the tail applied it. Resends it to whichever node is our
+ * successor after a failure (see the reconfiguration notes above).
+ *
+ * NOTE: must hold the key's lock (see keyLocks)
+ */
+func (server *KvServerImpl) passDownChain(ctx context.Context, shard int, key string) error {
+	// the write is applied here already: let the rest of the chain get it even
+	// if the caller gives up
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), chainWriteTimeout)
+	defer cancel()
+
+	request := &proto.ChainWriteRequest{Predecessor: server.nodeName, Key: []byte(key), Value: server.keyState(shard, key)}
+	lastSuccessor := ""
+	for {
+		_, successor, inChain := server.chainNeighbors(shard)
+		if !inChain {
+			return status.Errorf(codes.FailedPrecondition, "no longer in the chain of shard %d", shard)
+		}
+		if successor == "" {
+			// we are the tail
+			return nil
+		}
+		if lastSuccessor != "" && successor != lastSuccessor {
+			server.stats.Add("chain_writes_resent", 1)
+		}
+		lastSuccessor = successor
+
+		client, err := server.clientPool.GetClient(successor)
+		if err == nil {
+			_, err = client.ChainWrite(ctx, request)
+		}
+		if err == nil {
+			server.stats.Add("chain_writes_forwarded", 1)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			server.stats.Add("chain_write_failures", 1)
+			return status.Errorf(codes.Unavailable, "failed to pass write down the chain to %s: %s", successor, status.Convert(err).Message())
+		case <-time.After(chainRetryDelay):
+		}
+	}
+}
+
+func (server *KvServerImpl) ChainWrite(
+	ctx context.Context,
+	request *proto.ChainWriteRequest,
+) (*proto.ChainWriteResponse, error) {
+	if len(request.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	if !server.chainReplication {
+		return nil, status.Error(codes.FailedPrecondition, "chain replication is off on this server")
+	}
+	key := string(request.Key)
+	shard, err := server.checkShardWritable(key)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := server.keyLocks.lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if predecessor, _, _ := server.chainNeighbors(shard); predecessor == "" || predecessor != request.Predecessor {
+		return nil, status.Errorf(codes.FailedPrecondition, "%q is not the predecessor of %q in the chain of shard %d", request.Predecessor, server.nodeName, shard)
+	}
+	if err := server.storeKeyState(shard, key, request.Value); err != nil {
+		return nil, err
+	}
+	server.stats.Add("chain_writes_applied", 1)
+	if err := server.passDownChain(ctx, shard, key); err != nil {
+		return nil, err
+	}
+	return &proto.ChainWriteResponse{}, nil
+}
@@ -3,59 +3,652 @@ package kv
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cs426.yale.edu/lab4/kv/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	// "google.golang.org/grpc"
 )
 
 type Kv struct {
 	shardMap   *ShardMap
 	clientPool ClientPool
+	options    KvOptions
+	stats      Stats
+	// stamps the values we write (see hlc.go)
+	clock hybridClock
 
 	mu        sync.Mutex
 	rrCounter map[int]int
+	// in Raft mode, the last leader seen for each shard
+	leaders map[int]string
+}
+
+/*
+ * How many replicas of a shard a call waits for before it completes.
+ */
+type ConsistencyLevel int
+
+const (
+	// The Kv's default for the kind of call (see KvOptions)
+	ConsistencyDefault ConsistencyLevel = iota
+	// A single replica
+	ConsistencyOne
+	// A majority of the replicas
+	ConsistencyQuorum
+	// Every replica
+	ConsistencyAll
+)
+
+// Number of replicas out of `n` which the level waits for
+func (level ConsistencyLevel) replicas(n int) int {
+	switch level {
+	case ConsistencyOne:
+		return min(1, n)
+	case ConsistencyQuorum:
+		return n/2 + 1
+	}
+	return n
+}
+
+// Parses the name of a level ("one", "quorum" or "all"); "" is ConsistencyDefault
+func ParseConsistencyLevel(name string) (ConsistencyLevel, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return ConsistencyDefault, nil
+	case "one":
+		return ConsistencyOne, nil
+	case "quorum":
+		return ConsistencyQuorum, nil
+	case "all":
+		return ConsistencyAll, nil
+	}
+	return ConsistencyDefault, fmt.Errorf("unknown consistency level: %q", name)
+}
+
+type KvOptions struct {
+	// Level for Get, GetWithVersion and GetBytes. Defaults to ConsistencyOne.
+	ReadConsistency ConsistencyLevel
+	// Level for Set, Delete, SetBytes and DeleteBytes. Defaults to ConsistencyAll.
+	WriteConsistency ConsistencyLevel
+	// When reads see replicas disagree, write the newest value back to the stale
+	// ones in the background (see readNewest). Only reads which contact several
+	// replicas, i.e. not at ConsistencyOne, can notice.
+	ReadRepair bool
+	// By default, when a write reaches some replicas of a shard but others are
+	// unreachable, a replica which applied it is asked to forward it to them
+	// once they are back (see StoreHint). This turns that off.
+	DisableHintedHandoff bool
+	// Send every write only to the primary of its shard (the first of its nodes
+	// in the ShardMap), which replicates it to the others. The servers must run
+	// with KvServerOptions.PrimaryBackup. Write consistency levels and hinted
+	// handoff do not apply then.
+	PrimaryBackup bool
+	// Send every call, reads included, to the Raft leader of its shard, which
+	// makes them linearizable. The servers must run with KvServerOptions.Raft.
+	// Consistency levels, read repair and hinted handoff do not apply then.
+	Raft bool
+	// Send every write to the head of its shard's chain (its first node), and
+	// reads to the tail (its last node). The servers must run with
+	// KvServerOptions.ChainReplication. Consistency levels, read repair and
+	// hinted handoff do not apply then.
+	ChainReplication bool
+}
+
+// How long a read repair write, or storing a hint, may take
+const (
+	readRepairTimeout = time.Second
+	hintTimeout       = time.Second
+)
+
+// How many times a write follows a NotPrimary redirect to another node, which
+// only happens while our ShardMap and the servers' disagree
+const maxPrimaryRedirects = 2
+
+// In Raft mode, how long a call keeps looking for the leader (e.g. during an
+// election) if its context has no earlier deadline, and how long it waits
+// before trying the nodes of the shard again
+const (
+	raftLeaderSearchTimeout = 3 * time.Second
+	raftLeaderRetryDelay    = 20 * time.Millisecond
+)
+
+type consistencyKey struct{}
+
+/*
+ * Returns a context which makes the Kv calls it is passed to run at `level`,
+ * instead of the Kv's default for reads or writes.
+ */
+func WithConsistency(ctx context.Context, level ConsistencyLevel) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, level)
 }
 
 func MakeKv(shardMap *ShardMap, clientPool ClientPool) *Kv {
+	return MakeKvWithOptions(shardMap, clientPool, KvOptions{})
+}
+
+func MakeKvWithOptions(shardMap *ShardMap, clientPool ClientPool, options KvOptions) *Kv {
+	if options.ReadConsistency == ConsistencyDefault {
+		options.ReadConsistency = ConsistencyOne
+	}
+	if options.WriteConsistency == ConsistencyDefault {
+		options.WriteConsistency = ConsistencyAll
+	}
 	return &Kv{
 		shardMap:   shardMap,
 		clientPool: clientPool,
+		options:    options,
 		rrCounter:  make(map[int]int),
+		leaders:    make(map[int]string),
+	}
+}
+
+// Level for a call made with ctx: the one set by WithConsistency, if any
+func (kv *Kv) consistency(ctx context.Context, write bool) ConsistencyLevel {
+	if level, ok := ctx.Value(consistencyKey{}).(ConsistencyLevel); ok && level != ConsistencyDefault {
+		return level
 	}
+	if write {
+		return kv.options.WriteConsistency
+	}
+	return kv.options.ReadConsistency
 }
 
 func (kv *Kv) Get(ctx context.Context, key string) (string, bool, error) {
+	value, _, wasFound, err := kv.GetWithVersion(ctx, key)
+	return value, wasFound, err
+}
+
+/*
+ * Like Get, but also returns the version of the value, which can be passed
+ * to CompareAndSet. The version is 0 if the key was not found.
+ *
+ * Reads as many replicas as the consistency level requires, and returns the most
+ * recently written value among them (by write timestamp, then version). A
+ * replica holding the key's tombstone reports when it was deleted, so the key
+ * counts as not found unless another replica has a value written since.
+ */
+func (kv *Kv) GetWithVersion(ctx context.Context, key string) (string, uint64, bool, error) {
+	newest, err := readNewest(kv, ctx, key,
+		func(client proto.KvClient) (*proto.GetResponse, error) {
+			return client.Get(ctx, &proto.GetRequest{Key: key})
+		},
+		func(response *proto.GetResponse) []byte { return []byte(response.Value) },
+	)
+	if err != nil || !newest.WasFound {
+		return "", 0, false, err
+	}
+	return newest.Value, newest.Version, newest.WasFound, nil
+}
+
+/*
+ * Counters kept by the client: read_repairs (stale replicas updated by read
+ * repair), read_repair_failures, hints_stored (writes handed off for replicas
+ * which missed them), hint_failures and primary_redirects (calls resent to the
+ * primary or Raft leader a server named, see callPrimary).
+ */
+func (kv *Kv) Stats() map[string]int64 {
+	return kv.stats.Snapshot()
+}
+
+// Implemented by GetResponse and GetBytesResponse
+type replicaResponse interface {
+	GetWasFound() bool
+	GetVersion() uint64
+	GetTimestamp() uint64
+	GetTtlMsRemaining() int64
+	GetNoExpiry() bool
+	GetDeleted() bool
+}
+
+/*
+ * Reads `key` from replicas (see readReplicas) and returns the most recently
+ * written value among the responses. `value` extracts the value of a response.
+ *
+ * With read repair enabled, replicas which returned an older value (or none) are
+ * sent the newest one through ReplicaWrite, in the background so the read does
+ * not wait for them. Replicas only apply it if they have nothing newer by then.
+ * When the newest response is a tombstone, it is sent the same way, so replicas
+ * which missed the delete drop the value they still have.
+ */
+func readNewest[T replicaResponse](kv *Kv, ctx context.Context, key string, call func(proto.KvClient) (T, error), value func(T) []byte) (T, error) {
+	if kv.options.Raft || kv.options.ChainReplication {
+		var response T
+		err := kv.callReader(ctx, key, func(client proto.KvClient) error {
+			var err error
+			response, err = call(client)
+			return err
+		})
+		if err == nil {
+			kv.clock.update(response.GetTimestamp())
+		}
+		return response, err
+	}
+	responses, nodes, err := readReplicas(kv, ctx, key, call)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	newest := responses[0]
+	for _, response := range responses[1:] {
+		if newerValue(response.GetTimestamp(), response.GetVersion(), newest.GetTimestamp(), newest.GetVersion()) {
+			newest = response
+		}
+	}
+	// so what we write next replaces what we read
+	kv.clock.update(newest.GetTimestamp())
+	if !kv.options.ReadRepair || !(newest.GetWasFound() || newest.GetDeleted()) {
+		return newest, nil
+	}
+
+	stale := make([]string, 0)
+	for i, response := range responses {
+		if newerValue(newest.GetTimestamp(), newest.GetVersion(), response.GetTimestamp(), response.GetVersion()) {
+			stale = append(stale, nodes[i])
+		}
+	}
+	if len(stale) > 0 {
+		kv.repair(stale, &proto.GetShardValue{
+			Key:            []byte(key),
+			Value:          value(newest),
+			TtlMsRemaining: newest.GetTtlMsRemaining(),
+			Version:        newest.GetVersion(),
+			NoExpiry:       newest.GetNoExpiry(),
+			Timestamp:      newest.GetTimestamp(),
+			Deleted:        newest.GetDeleted(),
+		})
+	}
+	return newest, nil
+}
+
+// Sends `value` to each of `nodes` in the background
+func (kv *Kv) repair(nodes []string, value *proto.GetShardValue) {
+	for _, node := range nodes {
+		go func(nodeName string) {
+			ctx, cancel := context.WithTimeout(context.Background(), readRepairTimeout)
+			defer cancel()
+			client, err := kv.clientPool.GetClient(nodeName)
+			var response *proto.ReplicaWriteResponse
+			if err == nil {
+				response, err = client.ReplicaWrite(ctx, &proto.ReplicaWriteRequest{Value: value})
+			}
+			if err != nil {
+				kv.stats.Add("read_repair_failures", 1)
+			} else if response.Applied {
+				kv.stats.Add("read_repairs", 1)
+			}
+		}(node)
+	}
+}
+
+/*
+ * Calls `call` on replicas of the shard for `key` until as many succeed as the
+ * read consistency level requires. Replicas are picked in round-robin order, with
+ * that many calls in parallel, and each failure moves on to the next replica.
+ * Returns the successful responses along with the node each came from, or the
+ * last error if too few replicas succeeded.
+ */
+func readReplicas[T any](kv *Kv, ctx context.Context, key string, call func(proto.KvClient) (T, error)) ([]T, []string, error) {
 	shard := GetShardForKey(key, kv.shardMap.NumShards())
-	nodes := kv.shardMap.NodesForShard(shard)
+	nodes := kv.replicaOrder(shard)
 
 	if len(nodes) == 0 {
-		return "", false, errors.New("no nodes available for shard")
+		return nil, nil, errors.New("no nodes available for shard")
+	}
+	needed := kv.consistency(ctx, false).replicas(len(nodes))
+
+	type result struct {
+		node     string
+		response T
+		err      error
+	}
+	// buffered so calls still running when we return do not block
+	results := make(chan result, len(nodes))
+	next := 0
+	start := func() {
+		node := nodes[next]
+		next++
+		go func() {
+			client, err := kv.clientPool.GetClient(node)
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			response, err := call(client)
+			results <- result{node, response, err}
+		}()
 	}
+	for next < needed {
+		start()
+	}
+
+	responses := make([]T, 0, needed)
+	responders := make([]string, 0, needed)
 	var lastErr error
-	for i := 0; i < len(nodes); i++ {
-		node := kv.getNextNode(shard, nodes)
-		client, err := kv.clientPool.GetClient(node)
-		if err != nil {
-			// try another node
-			lastErr = err
+	for inFlight := needed; inFlight > 0; inFlight-- {
+		result := <-results
+		if result.err != nil {
+			lastErr = result.err
+			if next < len(nodes) {
+				// try another node
+				start()
+				inFlight++
+			}
 			continue
 		}
+		responses = append(responses, result.response)
+		responders = append(responders, result.node)
+		if len(responses) == needed {
+			return responses, responders, nil
+		}
+	}
+	return nil, nil, lastErr // propagate the last error
+}
+
+func (kv *Kv) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	timestamp := kv.clock.now()
+	return kv.writeReplicas(ctx, key, func(ctx context.Context, client proto.KvClient) error {
+		_, err := client.Set(ctx, &proto.SetRequest{Key: key, Value: value, TtlMs: ttl.Milliseconds(), Timestamp: timestamp})
+		return err
+	})
+}
+
+func (kv *Kv) Delete(ctx context.Context, key string) error {
+	timestamp := kv.clock.now()
+	return kv.writeReplicas(ctx, key, func(ctx context.Context, client proto.KvClient) error {
+		_, err := client.Delete(ctx, &proto.DeleteRequest{Key: key, Timestamp: timestamp})
+		return err
+	})
+}
+
+/*
+ * Calls `call` on every replica of the shard for `key` in parallel, and returns
+ * as soon as as many succeeded as the write consistency level requires. Otherwise
+ * waits for every replica and returns the first error encountered.
+ *
+ * Calls still running when we return carry on, so every replica gets the write
+ * even if the caller cancels ctx afterwards; they keep its deadline though. Once
+ * they are all done, replicas which could not be reached get hints (see storeHints).
+ */
+func (kv *Kv) writeReplicas(ctx context.Context, key string, call func(context.Context, proto.KvClient) error) error {
+	if kv.options.PrimaryBackup || kv.options.Raft || kv.options.ChainReplication {
+		return kv.callPrimary(ctx, key, func(client proto.KvClient) error {
+			return call(ctx, client)
+		})
+	}
+	shard := GetShardForKey(key, kv.shardMap.NumShards())
+	nodes := kv.shardMap.NodesForShard(shard)
+
+	if len(nodes) == 0 {
+		return errors.New("no nodes available for shard")
+	}
+	needed := kv.consistency(ctx, true).replicas(len(nodes))
+
+	callCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+	if deadline, ok := ctx.Deadline(); ok {
+		callCtx, cancel = context.WithDeadline(callCtx, deadline)
+	}
+
+	// buffered so calls still running when we return do not block
+	errs := make(chan error, len(nodes))
+	acks := make([]bool, len(nodes))
+	unreachable := make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, nodeName string) {
+			defer wg.Done()
+			client, err := kv.clientPool.GetClient(nodeName)
+			if err == nil {
+				err = call(callCtx, client)
+			} else {
+				unreachable[i] = true
+			}
+			acks[i] = err == nil
+			if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+				unreachable[i] = true
+			}
+			errs <- err
+		}(i, node)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		if !kv.options.DisableHintedHandoff {
+			kv.storeHints(key, nodes, acks, unreachable)
+		}
+	}()
 
-		response, err := client.Get(ctx, &proto.GetRequest{Key: key})
+	acked := 0
+	var firstErr error
+	for range nodes {
+		select {
+		case err := <-errs:
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			acked++
+			if acked == needed {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
+
+/*
+ * Sends a call to the primary of the shard for `key` alone (see
+ * KvOptions.PrimaryBackup), to its Raft leader (see KvOptions.Raft), or to the
+ * head of its chain (see KvOptions.ChainReplication). If the node answers that
+ * another one is the primary, the call is sent there instead.
+ *
+ * In Raft mode, calls start at the last leader seen for the shard, and while no
+ * leader is known (or it is unreachable) the shard's nodes are tried in turn
+ * until one is, or raftLeaderSearchTimeout passes. Calls are only retried after
+ * errors which mean they were not applied: unreachable nodes, and nodes which
+ * are not the leader (or lost leadership before the write was committed).
+ */
+func (kv *Kv) callPrimary(ctx context.Context, key string, call func(proto.KvClient) error) error {
+	shard := GetShardForKey(key, kv.shardMap.NumShards())
+	nodes := kv.shardMap.NodesForShard(shard)
+
+	if len(nodes) == 0 {
+		return errors.New("no nodes available for shard")
+	}
+	if !kv.options.Raft {
+		return kv.followRedirects(nodes[0], call)
+	}
+
+	deadline := time.Now().Add(raftLeaderSearchTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	node := kv.leaderOf(shard, nodes)
+	next := slices.Index(nodes, node) + 1
+	for {
+		client, err := kv.clientPool.GetClient(node)
 		if err == nil {
-			// return the first successful response from any node
-			return response.Value, response.WasFound, nil
-		} else {
-			lastErr = err
+			err = call(client)
+		}
+		if err == nil {
+			kv.setLeader(shard, node)
+			return nil
 		}
+		leader, redirected := primaryRedirect(err)
+		if !redirected && status.Code(err) != codes.Unavailable && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if redirected && leader != "" && leader != node {
+			kv.stats.Add("primary_redirects", 1)
+			node = leader
+			continue
+		}
+		// no leader known there: try the next node, pausing after each round
+		if next%len(nodes) == 0 {
+			if time.Now().Add(raftLeaderRetryDelay).After(deadline) {
+				return err
+			}
+			select {
+			case <-time.After(raftLeaderRetryDelay):
+			case <-ctx.Done():
+				return err
+			}
+		}
+		node = nodes[next%len(nodes)]
+		next++
+	}
+}
+
+/*
+ * Sends a read to the node which serves it in the replication mode: the tail of
+ * the chain in chain replication mode, otherwise the primary (see callPrimary).
+ */
+func (kv *Kv) callReader(ctx context.Context, key string, call func(proto.KvClient) error) error {
+	if !kv.options.ChainReplication {
+		return kv.callPrimary(ctx, key, call)
+	}
+	nodes := kv.shardMap.NodesForShard(GetShardForKey(key, kv.shardMap.NumShards()))
+	if len(nodes) == 0 {
+		return errors.New("no nodes available for shard")
 	}
+	return kv.followRedirects(nodes[len(nodes)-1], call)
+}
 
-	return "", false, lastErr // propagate the last error
+// Calls `node`, then the node its NotPrimary error names if any, up to maxPrimaryRedirects times
+func (kv *Kv) followRedirects(node string, call func(proto.KvClient) error) error {
+	for redirects := 0; ; redirects++ {
+		client, err := kv.clientPool.GetClient(node)
+		if err != nil {
+			return err
+		}
+		err = call(client)
+		primary, redirected := primaryRedirect(err)
+		if !redirected || primary == "" || primary == node || redirects == maxPrimaryRedirects {
+			return err
+		}
+		kv.stats.Add("primary_redirects", 1)
+		node = primary
+	}
 }
 
-func (kv *Kv) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+// The last leader seen for `shard`, or else its first node
+func (kv *Kv) leaderOf(shard int, nodes []string) string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	if leader, known := kv.leaders[shard]; known {
+		return leader
+	}
+	return nodes[0]
+}
+
+func (kv *Kv) setLeader(shard int, node string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.leaders[shard] = node
+}
+
+// The primary named by a NotPrimary error, if err is one
+func primaryRedirect(err error) (string, bool) {
+	if status.Code(err) != codes.FailedPrecondition {
+		return "", false
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if notPrimary, ok := detail.(*proto.NotPrimary); ok {
+			return notPrimary.Primary, true
+		}
+	}
+	return "", false
+}
+
+/*
+ * Asks a replica which acked a write to `key` to forward it to each replica which
+ * was unreachable, once that one is back (hinted handoff). Tries the acked
+ * replicas in order until one stores the hint.
+ */
+func (kv *Kv) storeHints(key string, nodes []string, acks []bool, unreachable []bool) {
+	for i, target := range nodes {
+		if !unreachable[i] {
+			continue
+		}
+		stored := false
+		for j, holder := range nodes {
+			if !acks[j] {
+				continue
+			}
+			client, err := kv.clientPool.GetClient(holder)
+			if err != nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), hintTimeout)
+			_, err = client.StoreHint(ctx, &proto.StoreHintRequest{Node: target, Key: []byte(key)})
+			cancel()
+			if err == nil {
+				stored = true
+				break
+			}
+		}
+		if stored {
+			kv.stats.Add("hints_stored", 1)
+		} else if slices.Contains(acks, true) {
+			kv.stats.Add("hint_failures", 1)
+		}
+	}
+}
+
+/*
+ * Binary-safe versions of Get/Set/Delete, for keys or values which are not valid
+ * UTF-8 (serialized protobufs, images, ...). They read and write the same data as
+ * the string calls, with the same replication behavior. Note that Get fails with
+ * FailedPrecondition on a value which is not valid UTF-8.
+ */
+func (kv *Kv) GetBytes(ctx context.Context, key []byte) ([]byte, bool, error) {
+	newest, err := readNewest(kv, ctx, string(key),
+		func(client proto.KvClient) (*proto.GetBytesResponse, error) {
+			return client.GetBytes(ctx, &proto.GetBytesRequest{Key: key})
+		},
+		func(response *proto.GetBytesResponse) []byte { return response.Value },
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	return newest.Value, newest.WasFound, nil
+}
+
+func (kv *Kv) SetBytes(ctx context.Context, key []byte, value []byte, ttl time.Duration) error {
+	timestamp := kv.clock.now()
+	return kv.writeReplicas(ctx, string(key), func(ctx context.Context, client proto.KvClient) error {
+		_, err := client.SetBytes(ctx, &proto.SetBytesRequest{Key: key, Value: value, TtlMs: ttl.Milliseconds(), Timestamp: timestamp})
+		return err
+	})
+}
+
+func (kv *Kv) DeleteBytes(ctx context.Context, key []byte) error {
+	timestamp := kv.clock.now()
+	return kv.writeReplicas(ctx, string(key), func(ctx context.Context, client proto.KvClient) error {
+		_, err := client.DeleteBytes(ctx, &proto.DeleteBytesRequest{Key: key, Timestamp: timestamp})
+		return err
+	})
+}
+
+/*
+ * Calls `call` on every replica of the shard for `key` in parallel, and returns
+ * the first error encountered, if any.
+ */
+func (kv *Kv) forEachReplica(ctx context.Context, key string, call func(proto.KvClient) error) error {
+	if kv.options.PrimaryBackup || kv.options.Raft || kv.options.ChainReplication {
+		return kv.callPrimary(ctx, key, call)
+	}
 	shard := GetShardForKey(key, kv.shardMap.NumShards())
 	nodes := kv.shardMap.NodesForShard(shard)
 
@@ -63,74 +656,516 @@ func (kv *Kv) Set(ctx context.Context, key string, value string, ttl time.Durati
 		return errors.New("no nodes available for shard")
 	}
 
+	errs := make([]error, len(nodes))
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(nodes)+1)
-
-	// NOTE: do we need to ensure data consistency across nodes?
-	for _, node := range nodes {
+	for i, node := range nodes {
 		wg.Add(1)
-		go func(nodeName string) {
+		go func(i int, nodeName string) {
 			defer wg.Done()
 			client, err := kv.clientPool.GetClient(nodeName)
 			if err != nil {
-				errChan <- err
+				errs[i] = err
 				return
 			}
+			errs[i] = call(client)
+		}(i, node)
+	}
+	wg.Wait()
 
-			_, err = client.Set(ctx, &proto.SetRequest{Key: key, Value: value, TtlMs: ttl.Milliseconds()})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+ * Sets `key` to `value` only if its current version is `expectedVersion` (0 meaning
+ * the key must not exist). Returns whether the value was written and the resulting
+ * version of the key.
+ *
+ * Like Set, the request is sent to every replica of the shard, and each replica
+ * compares against its own version. Replicas which received the same writes agree;
+ * if they do not (e.g. after a partially failed Set), some may swap while others
+ * refuse, in which case an Aborted error is returned and the caller should re-read
+ * the key and retry. In primary-backup, Raft and chain replication modes, only
+ * the primary (or leader, or head of the chain) compares versions.
+ */
+func (kv *Kv) CompareAndSet(ctx context.Context, key string, value string, ttl time.Duration, expectedVersion uint64) (bool, uint64, error) {
+	request := &proto.CompareAndSetRequest{
+		Key:             key,
+		Value:           value,
+		TtlMs:           ttl.Milliseconds(),
+		ExpectedVersion: expectedVersion,
+		Timestamp:       kv.clock.now(),
+	}
+	if kv.options.PrimaryBackup || kv.options.Raft || kv.options.ChainReplication {
+		var response *proto.CompareAndSetResponse
+		err := kv.callPrimary(ctx, key, func(client proto.KvClient) error {
+			var err error
+			response, err = client.CompareAndSet(ctx, request)
+			return err
+		})
+		if err != nil {
+			return false, 0, err
+		}
+		return response.Swapped, response.Version, nil
+	}
+
+	shard := GetShardForKey(key, kv.shardMap.NumShards())
+	nodes := kv.shardMap.NodesForShard(shard)
+
+	if len(nodes) == 0 {
+		return false, 0, errors.New("no nodes available for shard")
+	}
+
+	responses := make([]*proto.CompareAndSetResponse, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, nodeName string) {
+			defer wg.Done()
+			client, err := kv.clientPool.GetClient(nodeName)
 			if err != nil {
-				errChan <- err
+				errs[i] = err
+				return
 			}
-		}(node)
+			responses[i], errs[i] = client.CompareAndSet(ctx, request)
+		}(i, node)
 	}
-
 	wg.Wait()
-	close(errChan)
 
-	if len(errChan) > 0 {
-		// Return the first error encountered
-		return <-errChan
+	for _, err := range errs {
+		if err != nil {
+			return false, 0, err
+		}
+	}
+	swapped := 0
+	for _, response := range responses {
+		if response.Swapped {
+			swapped++
+		}
+	}
+	if swapped != 0 && swapped != len(responses) {
+		return false, 0, status.Errorf(codes.Aborted, "replicas of shard %d disagree on the version of the key", shard)
 	}
+	return swapped != 0, responses[0].Version, nil
+}
 
-	return nil
+// Returned by GetTTL for keys which never expire (see Persist)
+const NoExpiry time.Duration = -1
+
+/*
+ * Returns the remaining TTL of `key` (NoExpiry if it was made persistent), and
+ * whether it was found. This reads from a single replica, whatever the read
+ * consistency level.
+ */
+func (kv *Kv) GetTTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	if kv.options.Raft || kv.options.ChainReplication {
+		var response *proto.GetTTLResponse
+		err := kv.callReader(ctx, key, func(client proto.KvClient) error {
+			var err error
+			response, err = client.GetTTL(ctx, &proto.GetTTLRequest{Key: key})
+			return err
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		if response.NoExpiry {
+			return NoExpiry, response.WasFound, nil
+		}
+		return time.Duration(response.TtlMsRemaining) * time.Millisecond, response.WasFound, nil
+	}
+	shard := GetShardForKey(key, kv.shardMap.NumShards())
+	nodes := kv.shardMap.NodesForShard(shard)
+
+	if len(nodes) == 0 {
+		return 0, false, errors.New("no nodes available for shard")
+	}
+	var lastErr error
+	for i := 0; i < len(nodes); i++ {
+		node := kv.getNextNode(shard, nodes)
+		client, err := kv.clientPool.GetClient(node)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		response, err := client.GetTTL(ctx, &proto.GetTTLRequest{Key: key})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if response.NoExpiry {
+			return NoExpiry, response.WasFound, nil
+		}
+		return time.Duration(response.TtlMsRemaining) * time.Millisecond, response.WasFound, nil
+	}
+
+	return 0, false, lastErr
 }
 
-func (kv *Kv) Delete(ctx context.Context, key string) error {
+/*
+ * Sets the TTL of an existing key to `ttl` from now, without changing its value.
+ * Like Set, this is applied on every replica. Returns whether the key was found
+ * (on at least one replica); missing or expired keys are not created.
+ */
+func (kv *Kv) Touch(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var wasFound atomic.Bool
+	timestamp := kv.clock.now()
+	err := kv.forEachReplica(ctx, key, func(client proto.KvClient) error {
+		response, err := client.Touch(ctx, &proto.TouchRequest{Key: key, TtlMs: ttl.Milliseconds(), Timestamp: timestamp})
+		if err == nil && response.WasFound {
+			wasFound.Store(true)
+		}
+		return err
+	})
+	return wasFound.Load(), err
+}
+
+/*
+ * Makes an existing key never expire, until it is next written with Set or
+ * given a TTL with Touch. Same fan-out and return value as Touch.
+ */
+func (kv *Kv) Persist(ctx context.Context, key string) (bool, error) {
+	var wasFound atomic.Bool
+	timestamp := kv.clock.now()
+	err := kv.forEachReplica(ctx, key, func(client proto.KvClient) error {
+		response, err := client.Persist(ctx, &proto.PersistRequest{Key: key, Timestamp: timestamp})
+		if err == nil && response.WasFound {
+			wasFound.Store(true)
+		}
+		return err
+	})
+	return wasFound.Load(), err
+}
+
+/*
+ * Atomically adds `delta` to the integer stored at `key` and returns the new value.
+ * A missing key counts as 0 and is created with the given ttl (none if 0); an
+ * existing key keeps its expiry. Fails with FailedPrecondition if the value is not a decimal
+ * int64, and with OutOfRange if the result would overflow.
+ *
+ * Like Set, the delta is applied on every replica of the shard, each of which
+ * increments its own copy atomically. The returned value is the one from the
+ * first replica in the shard's node list; replicas only disagree if an earlier
+ * write reached some of them but not others.
+ *
+ * Increments are not idempotent: if an error is returned the delta may still
+ * have been applied on some replicas, so blindly retrying can count twice.
+ */
+func (kv *Kv) Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	timestamp := kv.clock.now()
+	return kv.addToCounter(ctx, key, func(client proto.KvClient) (int64, error) {
+		response, err := client.Increment(ctx, &proto.IncrementRequest{Key: key, Delta: delta, TtlMs: ttl.Milliseconds(), Timestamp: timestamp})
+		if err != nil {
+			return 0, err
+		}
+		return response.Value, nil
+	})
+}
+
+/*
+ * Atomically subtracts `delta` from the integer stored at `key`. Same semantics
+ * as Increment.
+ */
+func (kv *Kv) Decrement(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	timestamp := kv.clock.now()
+	return kv.addToCounter(ctx, key, func(client proto.KvClient) (int64, error) {
+		response, err := client.Decrement(ctx, &proto.DecrementRequest{Key: key, Delta: delta, TtlMs: ttl.Milliseconds(), Timestamp: timestamp})
+		if err != nil {
+			return 0, err
+		}
+		return response.Value, nil
+	})
+}
+
+func (kv *Kv) addToCounter(ctx context.Context, key string, call func(proto.KvClient) (int64, error)) (int64, error) {
+	if kv.options.PrimaryBackup || kv.options.Raft || kv.options.ChainReplication {
+		var value int64
+		err := kv.callPrimary(ctx, key, func(client proto.KvClient) error {
+			var err error
+			value, err = call(client)
+			return err
+		})
+		return value, err
+	}
 	shard := GetShardForKey(key, kv.shardMap.NumShards())
 	nodes := kv.shardMap.NodesForShard(shard)
 
 	if len(nodes) == 0 {
-		return errors.New("no nodes available for shard")
+		return 0, errors.New("no nodes available for shard")
 	}
 
+	values := make([]int64, len(nodes))
+	errs := make([]error, len(nodes))
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(nodes)+1)
-
-	for _, node := range nodes {
+	for i, node := range nodes {
 		wg.Add(1)
-		go func(nodeName string) {
+		go func(i int, nodeName string) {
 			defer wg.Done()
 			client, err := kv.clientPool.GetClient(nodeName)
 			if err != nil {
-				errChan <- err
+				errs[i] = err
 				return
 			}
+			values[i], errs[i] = call(client)
+		}(i, node)
+	}
+	wg.Wait()
 
-			_, err = client.Delete(ctx, &proto.DeleteRequest{Key: key})
-			if err != nil {
-				errChan <- err
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return values[0], nil
+}
+
+/*
+ * Result for one key of a MultiGet: Err is set if the key could not be read
+ * from any replica, in which case Value and WasFound are meaningless.
+ */
+type GetResult struct {
+	Value    string
+	WasFound bool
+	Err      error
+}
+
+/*
+ * Reads many keys at once. Keys are grouped by shard and each shard is read from
+ * one replica (picked round-robin like Get), so there is one MultiGet RPC per node
+ * involved, all sent in parallel. Keys which fail on that replica (the RPC failed,
+ * or the node does not host the key's shard) are retried on the shard's other
+ * replicas, again batched per node.
+ *
+ * Returns one result per distinct key.
+ */
+func (kv *Kv) MultiGet(ctx context.Context, keys []string) map[string]GetResult {
+	results := make(map[string]GetResult)
+	numShards := kv.shardMap.NumShards()
+
+	// replicas of each shard, in the order they will be tried
+	replicas := make(map[int][]string)
+	pending := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, seen := results[key]; seen {
+			continue
+		}
+		shard := GetShardForKey(key, numShards)
+		if _, ok := replicas[shard]; !ok {
+			replicas[shard] = kv.replicaOrder(shard)
+		}
+		if len(replicas[shard]) == 0 {
+			results[key] = GetResult{Err: errors.New("no nodes available for shard")}
+			continue
+		}
+		// placeholder so duplicates are skipped; overwritten below
+		results[key] = GetResult{}
+		pending = append(pending, key)
+	}
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		batches := make(map[string][]string)
+		for _, key := range pending {
+			nodes := replicas[GetShardForKey(key, numShards)]
+			node := nodes[attempt]
+			batches[node] = append(batches[node], key)
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for node, batch := range batches {
+			wg.Add(1)
+			go func(nodeName string, batch []string) {
+				defer wg.Done()
+				batchResults := make(map[string]GetResult, len(batch))
+				client, err := kv.clientPool.GetClient(nodeName)
+				var response *proto.MultiGetResponse
+				if err == nil {
+					response, err = client.MultiGet(ctx, &proto.MultiGetRequest{Keys: batch})
+				}
+				if err != nil {
+					for _, key := range batch {
+						batchResults[key] = GetResult{Err: err}
+					}
+				} else {
+					for _, value := range response.Values {
+						batchResults[value.Key] = GetResult{
+							Value:    value.Value,
+							WasFound: value.WasFound,
+							Err:      keyStatusError(value.Status),
+						}
+					}
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				for key, result := range batchResults {
+					results[key] = result
+				}
+			}(node, batch)
+		}
+		wg.Wait()
+
+		// retry failed keys on the next replica of their shard, if there is one
+		retry := make([]string, 0)
+		for _, key := range pending {
+			if results[key].Err != nil && attempt+1 < len(replicas[GetShardForKey(key, numShards)]) {
+				retry = append(retry, key)
 			}
-		}(node)
+		}
+		pending = retry
+	}
+
+	return results
+}
+
+/*
+ * Sets many keys at once, all with the same ttl. Like Set, every key is written to
+ * every replica of its shard; keys are grouped so there is one MultiSet RPC per
+ * node involved, all sent in parallel.
+ *
+ * Returns the keys which could not be written (on at least one replica) with the
+ * first error encountered for each; the map is empty if every write succeeded.
+ */
+func (kv *Kv) MultiSet(ctx context.Context, values map[string]string, ttl time.Duration) map[string]error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	// every replica of a key must get the same timestamp
+	timestamp := kv.clock.now()
+	return kv.multiWrite(keys, func(client proto.KvClient, batch []string) ([]*proto.KeyStatus, error) {
+		entries := make([]*proto.SetRequest, len(batch))
+		for i, key := range batch {
+			entries[i] = &proto.SetRequest{Key: key, Value: values[key], TtlMs: ttl.Milliseconds(), Timestamp: timestamp}
+		}
+		response, err := client.MultiSet(ctx, &proto.MultiSetRequest{Entries: entries})
+		if err != nil {
+			return nil, err
+		}
+		return response.Statuses, nil
+	})
+}
+
+/*
+ * Deletes many keys at once from every replica. Same batching and error
+ * reporting as MultiSet.
+ */
+func (kv *Kv) MultiDelete(ctx context.Context, keys []string) map[string]error {
+	timestamp := kv.clock.now()
+	return kv.multiWrite(keys, func(client proto.KvClient, batch []string) ([]*proto.KeyStatus, error) {
+		response, err := client.MultiDelete(ctx, &proto.MultiDeleteRequest{Keys: batch, Timestamp: timestamp})
+		if err != nil {
+			return nil, err
+		}
+		return response.Statuses, nil
+	})
+}
+
+/*
+ * Sends each key to every replica of its shard (only the primary in primary-backup
+ * mode, the head of the chain in chain replication mode, and the last leader seen
+ * in Raft mode), batched into one call per node.
+ * Returns the first error seen for each key which failed anywhere.
+ */
+func (kv *Kv) multiWrite(keys []string, call func(client proto.KvClient, batch []string) ([]*proto.KeyStatus, error)) map[string]error {
+	errs := make(map[string]error)
+	numShards := kv.shardMap.NumShards()
+
+	batches := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		nodes := kv.shardMap.NodesForShard(GetShardForKey(key, numShards))
+		if len(nodes) == 0 {
+			errs[key] = errors.New("no nodes available for shard")
+			continue
+		}
+		if kv.options.PrimaryBackup || kv.options.ChainReplication {
+			nodes = nodes[:1]
+		} else if kv.options.Raft {
+			nodes = []string{kv.leaderOf(GetShardForKey(key, numShards), nodes)}
+		}
+		for _, node := range nodes {
+			batches[node] = append(batches[node], key)
+		}
 	}
 
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for node, batch := range batches {
+		wg.Add(1)
+		go func(nodeName string, batch []string) {
+			defer wg.Done()
+			client, err := kv.clientPool.GetClient(nodeName)
+			var statuses []*proto.KeyStatus
+			if err == nil {
+				statuses, err = call(client, batch)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				for _, key := range batch {
+					if errs[key] == nil {
+						errs[key] = err
+					}
+				}
+				return
+			}
+			for _, keyStatus := range statuses {
+				if err := keyStatusError(keyStatus); err != nil && errs[keyStatus.Key] == nil {
+					errs[keyStatus.Key] = err
+				}
+			}
+		}(node, batch)
+	}
 	wg.Wait()
-	close(errChan)
 
-	if len(errChan) > 0 {
-		return <-errChan // Return the first error encountered
+	return errs
+}
+
+func keyStatusError(keyStatus *proto.KeyStatus) error {
+	if keyStatus == nil || codes.Code(keyStatus.Code) == codes.OK {
+		return nil
 	}
+	return status.Error(codes.Code(keyStatus.Code), keyStatus.Message)
+}
 
-	return nil
+/*
+ * Replicas of `shard` starting from the next one in round-robin order, so that
+ * batched reads spread load the same way Get does. In Raft mode, starting from
+ * the last leader seen instead, as only the leader serves reads, and in chain
+ * replication mode from the tail of the chain.
+ */
+func (kv *Kv) replicaOrder(shard int) []string {
+	nodes := kv.shardMap.NodesForShard(shard)
+	if len(nodes) == 0 {
+		return nodes
+	}
+	var first string
+	if kv.options.Raft {
+		first = kv.leaderOf(shard, nodes)
+	} else if kv.options.ChainReplication {
+		first = nodes[len(nodes)-1]
+	} else {
+		first = kv.getNextNode(shard, nodes)
+	}
+	start := 0
+	for i, node := range nodes {
+		if node == first {
+			start = i
+			break
+		}
+	}
+	return append(nodes[start:len(nodes):len(nodes)], nodes[:start]...)
 }
 
 func (kv *Kv) getNextNode(shard int, nodes []string) string {
@@ -0,0 +1,214 @@
+package kv
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Client writes are turned into WriteCommands, which execute applies according
+ * to the replication mode: directly, through the primary (primary.go), or once
+ * committed in the shard's Raft log (raft.go). Everything a command depends on
+ * is in it, including the time it was accepted at, so applying it on another
+ * replica, or later, gives the same result.
+ *
+ * Command timestamps order writes to a key (last-writer-wins): a SET or DELETE
+ * older than the value or tombstone (see entry.deleted) of its key is ignored,
+ * since replicas may receive concurrent writes in different orders, while
+ * writes computed from the current value (COMPARE_AND_SET, INCREMENT,
+ * DECREMENT) are stamped after it.
+ */
+
+// Outcome of a command, for the RPC which issued it
+type writeResult struct {
+	// version of the key after the write (COMPARE_AND_SET, INCREMENT, DECREMENT, SET)
+	version uint64
+	// COMPARE_AND_SET
+	swapped bool
+	// INCREMENT and DECREMENT
+	counter int64
+	// SET_EXPIRY
+	wasFound bool
+}
+
+// Write time of a command: the one given by the client, or now (see hlc.go)
+func (server *KvServerImpl) commandTimestamp(timestamp uint64) uint64 {
+	if timestamp == 0 {
+		return server.clock.now()
+	}
+	server.clock.update(timestamp)
+	return timestamp
+}
+
+// Timestamp of a write computed from `current`: the command's, unless that is
+// not after current's
+func timestampAfter(current *entry, exists bool, timestamp uint64) uint64 {
+	if exists && current.timestamp >= timestamp {
+		return current.timestamp + 1
+	}
+	return timestamp
+}
+
+func (server *KvServerImpl) execute(ctx context.Context, shard int, command *proto.WriteCommand) (writeResult, error) {
+	command.NowMs = uint64(time.Now().UnixMilli())
+	if server.raft != nil {
+		return server.raftWrite(ctx, shard, command)
+	}
+	var result writeResult
+	err := server.clientWrite(ctx, shard, string(command.Key), func() error {
+		var err error
+		result, err = server.applyCommand(shard, command)
+		return err
+	})
+	return result, err
+}
+
+/*
+ * Fails client reads of `shard` which the replication mode does not let us
+ * serve, and in Raft mode waits until they are linearizable.
+ */
+func (server *KvServerImpl) checkReadable(ctx context.Context, shard int) error {
+	if err := server.checkChainTail(shard); err != nil {
+		return err
+	}
+	return server.raftRead(ctx, shard)
+}
+
+func (server *KvServerImpl) applyCommand(shard int, command *proto.WriteCommand) (writeResult, error) {
+	server.locks[shard-1].Lock()
+	defer server.locks[shard-1].Unlock()
+	if server.data[shard-1] == nil {
+		return writeResult{}, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+
+	key := string(command.Key)
+	now := command.NowMs
+	switch command.Op {
+	case proto.WriteCommand_SET:
+		if server.superseded(shard, command) {
+			return writeResult{}, nil
+		}
+		e, err := server.writeEntry(shard, key, string(command.Value), command.ExpiryMs, command.Timestamp)
+		if err != nil {
+			return writeResult{}, err
+		}
+		return writeResult{version: e.version}, nil
+	case proto.WriteCommand_DELETE:
+		if server.superseded(shard, command) {
+			return writeResult{}, nil
+		}
+		return writeResult{}, server.deleteEntry(shard, key, command.Timestamp, now)
+	case proto.WriteCommand_COMPARE_AND_SET:
+		return server.compareAndSetEntry(shard, command, now)
+	case proto.WriteCommand_INCREMENT, proto.WriteCommand_DECREMENT:
+		return server.addToCounterEntry(shard, command, now)
+	case proto.WriteCommand_SET_EXPIRY:
+		return server.setExpiryEntry(shard, key, command.ExpiryMs, command.Timestamp, now)
+	}
+	return writeResult{}, status.Errorf(codes.InvalidArgument, "unknown write %v", command.Op)
+}
+
+/*
+ * Whether a SET or DELETE is older than the value or tombstone we have for its
+ * key, in which case it is ignored: a later write got here first.
+ *
+ * NOTE: must hold the lock for the shard
+ */
+func (server *KvServerImpl) superseded(shard int, command *proto.WriteCommand) bool {
+	current, exists := server.data[shard-1].get(string(command.Key))
+	if exists && current.ttl >= command.NowMs && !newerValue(command.Timestamp, 0, current.timestamp, 0) {
+		server.stats.Add("stale_writes_ignored", 1)
+		return true
+	}
+	return false
+}
+
+// NOTE: must hold the (write) lock for the shard
+func (server *KvServerImpl) compareAndSetEntry(shard int, command *proto.WriteCommand, now uint64) (writeResult, error) {
+	key := string(command.Key)
+	currentVersion := uint64(0)
+	current, exists := server.data[shard-1].get(key)
+	if exists && current.live(now) {
+		currentVersion = current.version
+	}
+	if currentVersion != command.ExpectedVersion {
+		return writeResult{version: currentVersion}, nil
+	}
+
+	timestamp := timestampAfter(current, exists, command.Timestamp)
+	newEntry, err := server.writeEntry(shard, key, string(command.Value), command.ExpiryMs, timestamp)
+	if err != nil {
+		return writeResult{}, err
+	}
+	return writeResult{swapped: true, version: newEntry.version}, nil
+}
+
+/*
+ * Parses the current value of the key as a decimal int64 and adds (or for
+ * DECREMENT, subtracts) the delta. An absent or expired key counts as 0 and is
+ * created with the command's expiry; an existing key keeps its own.
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) addToCounterEntry(shard int, command *proto.WriteCommand, now uint64) (writeResult, error) {
+	key, delta := string(command.Key), command.Delta
+	current := int64(0)
+	ttl := command.ExpiryMs
+	existing, exists := server.data[shard-1].get(key)
+	if exists && existing.live(now) {
+		var err error
+		current, err = strconv.ParseInt(existing.value, 10, 64)
+		if err != nil {
+			return writeResult{}, status.Errorf(codes.FailedPrecondition, "value of %q is not an integer", key)
+		}
+		ttl = existing.ttl
+	}
+
+	var result int64
+	var overflow bool
+	if command.Op == proto.WriteCommand_DECREMENT {
+		result = current - delta
+		overflow = (delta > 0 && result > current) || (delta < 0 && result < current)
+	} else {
+		result = current + delta
+		overflow = (delta > 0 && result < current) || (delta < 0 && result > current)
+	}
+	if overflow {
+		return writeResult{}, status.Errorf(codes.OutOfRange, "counter %q would overflow", key)
+	}
+
+	timestamp := timestampAfter(existing, exists, command.Timestamp)
+	newEntry, err := server.writeEntry(shard, key, strconv.FormatInt(result, 10), ttl, timestamp)
+	if err != nil {
+		return writeResult{}, err
+	}
+	return writeResult{counter: result, version: newEntry.version}, nil
+}
+
+/*
+ * Changes the expiration timestamp of a live key, keeping its value and version.
+ * Expired keys are not revived. The change takes the command's timestamp, so it
+ * wins over the old expiry on replicas which missed it (see newerValue).
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) setExpiryEntry(shard int, key string, ttl uint64, timestamp uint64, now uint64) (writeResult, error) {
+	e, exists := server.data[shard-1].get(key)
+	if !exists || !e.live(now) {
+		return writeResult{}, nil
+	}
+	updated := &entry{key: e.key, value: e.value, ttl: ttl, version: e.version, timestamp: timestampAfter(e, exists, timestamp)}
+	if server.persistence != nil {
+		if err := server.persistence.logSet(shard, updated); err != nil {
+			return writeResult{}, status.Errorf(codes.Internal, "failed to log write: %v", err)
+		}
+	}
+	// stored entries are never modified, since snapshots may share them
+	server.putEntry(shard, updated)
+	return writeResult{wasFound: true, version: e.version}, nil
+}
@@ -0,0 +1,189 @@
+package kv
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+/*
+ * Eviction policies used when a server is given a memory budget. There is one
+ * policy instance per shard, which tracks the entries of that shard and picks
+ * which one to evict next.
+ *
+ * added/removed/victim/reset are called while holding the write lock for the shard.
+ * accessed is called from Get, which only holds the read lock, so policies which
+ * track accesses need their own mutex.
+ */
+type evictionPolicy interface {
+	added(e *entry)
+	removed(e *entry)
+	accessed(e *entry)
+	// next entry to evict, or nil if the shard is empty
+	victim() *entry
+	reset()
+}
+
+const (
+	EvictLRU        = "lru"
+	EvictLFU        = "lfu"
+	EvictTTLSoonest = "ttl"
+)
+
+// soonest returns the shard's entry which expires first (see ShardStore.soonest)
+func newEvictionPolicy(name string, soonest func() *entry) (evictionPolicy, error) {
+	switch name {
+	case EvictLRU, "":
+		return &lruPolicy{order: list.New()}, nil
+	case EvictLFU:
+		return &lfuPolicy{}, nil
+	case EvictTTLSoonest:
+		return &ttlPolicy{soonest: soonest}, nil
+	}
+	return nil, fmt.Errorf("unknown eviction policy: %q", name)
+}
+
+/*
+ * Least recently used: entries are kept in a list ordered by last access,
+ * most recent at the front.
+ */
+type lruPolicy struct {
+	mutex sync.Mutex
+	order *list.List
+}
+
+func (p *lruPolicy) added(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	e.lruElement = p.order.PushFront(e)
+}
+
+func (p *lruPolicy) removed(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e.lruElement != nil {
+		p.order.Remove(e.lruElement)
+		e.lruElement = nil
+	}
+}
+
+func (p *lruPolicy) accessed(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e.lruElement != nil {
+		p.order.MoveToFront(e.lruElement)
+	}
+}
+
+func (p *lruPolicy) victim() *entry {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	back := p.order.Back()
+	if back == nil {
+		return nil
+	}
+	return back.Value.(*entry)
+}
+
+func (p *lruPolicy) reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.order.Init()
+}
+
+/*
+ * Least frequently used: a min-heap on the number of accesses since the entry
+ * was written, plus one for the write. Ties go to the entry written first.
+ */
+type lfuPolicy struct {
+	mutex   sync.Mutex
+	entries lfuHeap
+}
+
+type lfuHeap []*entry
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].frequency != h[j].frequency {
+		return h[i].frequency < h[j].frequency
+	}
+	return h[i].timestamp < h[j].timestamp
+}
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].lfuIndex = i
+	h[j].lfuIndex = j
+}
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.lfuIndex = len(*h)
+	*h = append(*h, e)
+}
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[0 : n-1]
+	e.lfuIndex = -1
+	return e
+}
+
+func (p *lfuPolicy) added(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	// counts the write itself, so a new entry is not evicted before older
+	// ones which were never read
+	e.frequency = 1
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfuPolicy) removed(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e.lfuIndex >= 0 && e.lfuIndex < len(p.entries) && p.entries[e.lfuIndex] == e {
+		heap.Remove(&p.entries, e.lfuIndex)
+	}
+}
+
+func (p *lfuPolicy) accessed(e *entry) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e.lfuIndex >= 0 && e.lfuIndex < len(p.entries) && p.entries[e.lfuIndex] == e {
+		e.frequency++
+		heap.Fix(&p.entries, e.lfuIndex)
+	}
+}
+
+func (p *lfuPolicy) victim() *entry {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[0]
+}
+
+func (p *lfuPolicy) reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.entries = nil
+}
+
+/*
+ * Evicts the entry which would expire soonest anyway. This needs no extra state:
+ * the shard's store can find that entry (tombstones aside, see putEntry).
+ */
+type ttlPolicy struct {
+	soonest func() *entry
+}
+
+func (p *ttlPolicy) added(e *entry)    {}
+func (p *ttlPolicy) removed(e *entry)  {}
+func (p *ttlPolicy) accessed(e *entry) {}
+func (p *ttlPolicy) reset()            {}
+
+func (p *ttlPolicy) victim() *entry {
+	return p.soonest()
+}
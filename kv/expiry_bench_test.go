@@ -0,0 +1,153 @@
+package kv
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+/*
+ * Compares the expiry timing wheel with the per-shard TTL heap the server used
+ * before it, under workloads shaped like cmd/stress: Sets with a fixed TTL on keys
+ * picked uniformly from a key space, with periodic cleanup. Each structure is
+ * cleaned at the interval the server used with it (3s for the heap, expiryTick for
+ * the wheel). Time is simulated, so the cost measured is that of the bookkeeping
+ * alone.
+ *
+ *	go test ./kv -run '^$' -bench Expiry
+ */
+
+type expiryBenchStore interface {
+	set(key string, ttl uint64)
+	// removes entries which expired before now, returning how many
+	expire(now uint64) int
+}
+
+// The previous scheme: a min-heap on ttl, popped until its top is in the future
+type heapItem struct {
+	key   string
+	ttl   uint64
+	index int
+}
+
+type heapItems []*heapItem
+
+func (h heapItems) Len() int           { return len(h) }
+func (h heapItems) Less(i, j int) bool { return h[i].ttl < h[j].ttl }
+func (h heapItems) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *heapItems) Push(x interface{}) {
+	item := x.(*heapItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+func (h *heapItems) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[0 : n-1]
+	item.index = -1
+	return item
+}
+
+type heapStore struct {
+	data  map[string]*heapItem
+	items heapItems
+}
+
+func (s *heapStore) set(key string, ttl uint64) {
+	if old, exists := s.data[key]; exists {
+		heap.Remove(&s.items, old.index)
+	}
+	item := &heapItem{key: key, ttl: ttl}
+	heap.Push(&s.items, item)
+	s.data[key] = item
+}
+
+func (s *heapStore) expire(now uint64) int {
+	expired := 0
+	for len(s.items) > 0 && s.items[0].ttl <= now {
+		item := heap.Pop(&s.items).(*heapItem)
+		delete(s.data, item.key)
+		expired++
+	}
+	return expired
+}
+
+type wheelStore struct {
+	data  map[string]*entry
+	wheel *timingWheel
+}
+
+func (s *wheelStore) set(key string, ttl uint64) {
+	if old, exists := s.data[key]; exists {
+		s.wheel.remove(old)
+	}
+	e := &entry{key: key, ttl: ttl}
+	s.wheel.add(e)
+	s.data[key] = e
+}
+
+func (s *wheelStore) expire(now uint64) int {
+	expired := s.wheel.advance(now)
+	for _, e := range expired {
+		delete(s.data, e.key)
+	}
+	return len(expired)
+}
+
+func benchmarkExpiry(b *testing.B, store expiryBenchStore, start uint64, cleanupEvery time.Duration, numKeys int, setQps int) {
+	const ttl = 2000 // ms, the cmd/stress default
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	rng := rand.New(rand.NewSource(1))
+	// simulated clock, in microseconds
+	nowUs := start * 1000
+	stepUs := uint64(time.Second.Microseconds()) / uint64(setQps)
+	cleanupMs := uint64(cleanupEvery.Milliseconds())
+
+	// start from a steady state with every key present
+	for i, key := range keys {
+		store.set(key, start+uint64(i%ttl))
+	}
+	nextCleanup := start + cleanupMs
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		now := nowUs / 1000
+		store.set(keys[rng.Intn(numKeys)], now+ttl)
+		if now >= nextCleanup {
+			store.expire(now)
+			nextCleanup += cleanupMs
+		}
+		nowUs += stepUs
+	}
+}
+
+func BenchmarkExpiry(b *testing.B) {
+	start := uint64(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	for _, numKeys := range []int{1000, 100000} {
+		for _, setQps := range []int{30, 1000, 100000} {
+			name := fmt.Sprintf("keys=%d/qps=%d", numKeys, setQps)
+			b.Run("heap/"+name, func(b *testing.B) {
+				store := &heapStore{data: make(map[string]*heapItem)}
+				benchmarkExpiry(b, store, start, 3*time.Second, numKeys, setQps)
+			})
+			b.Run("wheel/"+name, func(b *testing.B) {
+				store := &wheelStore{
+					data:  make(map[string]*entry),
+					wheel: newTimingWheel(uint64(expiryTick.Milliseconds()), start),
+				}
+				benchmarkExpiry(b, store, start, expiryTick, numKeys, setQps)
+			})
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package kv
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Hinted handoff. When a client's write reaches some replicas of a shard but
+ * others are unreachable, it asks one which applied the write to store a hint for
+ * each replica which missed it (StoreHint). Hints only name the key: once the
+ * node is reachable again, we send it whatever we hold for the key by then (its
+ * value or tombstone, through ReplicaWrite), which also covers any later writes
+ * to the key it missed. If we hold nothing for the key any more, the hint is
+ * dropped (see replayHint).
+ *
+ * Hints are kept in memory, at most MaxHints of them per server. They are dropped
+ * once older than HintTTL, or once the ShardMap no longer assigns their shard to
+ * the node (or to us).
+ */
+
+const (
+	defaultMaxHints    = 10000
+	defaultHintTTL     = time.Hour
+	hintReplayInterval = time.Second
+	// how long replaying one hint may take before the node is considered down
+	hintReplayTimeout = time.Second
+)
+
+type hint struct {
+	shard   int
+	created time.Time
+	// changes whenever the hint is stored again, so that a replay which raced
+	// with a newer write does not drop the hint for it
+	seq uint64
+}
+
+type hintStore struct {
+	mutex sync.Mutex
+	// target node -> key -> hint
+	hints    map[string]map[string]hint
+	count    int
+	seq      uint64
+	maxHints int
+	ttl      time.Duration
+}
+
+func newHintStore(maxHints int, ttl time.Duration) *hintStore {
+	if maxHints <= 0 {
+		maxHints = defaultMaxHints
+	}
+	if ttl <= 0 {
+		ttl = defaultHintTTL
+	}
+	return &hintStore{hints: make(map[string]map[string]hint), maxHints: maxHints, ttl: ttl}
+}
+
+// Stores (or refreshes) a hint that `node` missed a write to `key`. Returns
+// false if that would exceed the limit.
+func (s *hintStore) add(node string, shard int, key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	keys := s.hints[node]
+	if _, exists := keys[key]; !exists {
+		if s.count >= s.maxHints {
+			return false
+		}
+		if keys == nil {
+			keys = make(map[string]hint)
+			s.hints[node] = keys
+		}
+		s.count++
+	}
+	s.seq++
+	keys[key] = hint{shard: shard, created: time.Now(), seq: s.seq}
+	return true
+}
+
+// Removes the hint for `key` unless it was stored again since it was read with
+// `seq`. Returns whether it was removed.
+func (s *hintStore) remove(node string, key string, seq uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	h, exists := s.hints[node][key]
+	if !exists || h.seq != seq {
+		return false
+	}
+	delete(s.hints[node], key)
+	if len(s.hints[node]) == 0 {
+		delete(s.hints, node)
+	}
+	s.count--
+	return true
+}
+
+// Copy of every hint, by target node
+func (s *hintStore) pending() map[string]map[string]hint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pending := make(map[string]map[string]hint, len(s.hints))
+	for node, keys := range s.hints {
+		pending[node] = make(map[string]hint, len(keys))
+		for key, h := range keys {
+			pending[node][key] = h
+		}
+	}
+	return pending
+}
+
+func (s *hintStore) size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+func (server *KvServerImpl) StoreHint(
+	ctx context.Context,
+	request *proto.StoreHintRequest,
+) (*proto.StoreHintResponse, error) {
+	if err := server.rejectInRaftMode(); err != nil {
+		return nil, err
+	}
+	if len(request.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	shard, err := server.checkShardAssignment(string(request.Key))
+	if err != nil {
+		return nil, err
+	}
+	if request.Node == server.nodeName || !slices.Contains(server.shardMap.NodesForShard(shard), request.Node) {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %q is not another replica of shard %d", request.Node, shard)
+	}
+	if !server.hints.add(request.Node, shard, string(request.Key)) {
+		server.stats.Add("hints_dropped", 1)
+		return nil, status.Error(codes.ResourceExhausted, "too many hints stored")
+	}
+	server.stats.Add("hints_stored", 1)
+	return &proto.StoreHintResponse{}, nil
+}
+
+func (server *KvServerImpl) hintLoop() {
+	ticker := time.NewTicker(hintReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-server.shutdown:
+			return
+		case <-ticker.C:
+			server.replayHints()
+		}
+	}
+}
+
+// Delivers the stored hints, to each node in parallel
+func (server *KvServerImpl) replayHints() {
+	var wg sync.WaitGroup
+	for node, hints := range server.hints.pending() {
+		wg.Add(1)
+		go func(node string, hints map[string]hint) {
+			defer wg.Done()
+			server.replayHintsTo(node, hints)
+		}(node, hints)
+	}
+	wg.Wait()
+}
+
+/*
+ * Delivers the hints for one node, dropping those which expired or no longer
+ * apply. Stops at the first delivery which fails: the node is likely still down,
+ * so the rest wait for the next round.
+ */
+func (server *KvServerImpl) replayHintsTo(node string, hints map[string]hint) {
+	var client proto.KvClient
+	now := time.Now()
+	for key, h := range hints {
+		if now.Sub(h.created) > server.hints.ttl {
+			if server.hints.remove(node, key, h.seq) {
+				server.stats.Add("hints_expired", 1)
+			}
+			continue
+		}
+		if !server.isShardHosted(h.shard) || !slices.Contains(server.shardMap.NodesForShard(h.shard), node) {
+			if server.hints.remove(node, key, h.seq) {
+				server.stats.Add("hints_dropped", 1)
+			}
+			continue
+		}
+
+		if client == nil {
+			var err error
+			client, err = server.clientPool.GetClient(node)
+			if err != nil {
+				return
+			}
+		}
+		sent, err := server.replayHint(client, h.shard, key)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"node": server.nodeName, "target": node}).Debugf("failed to replay hint: %q", err)
+			return
+		}
+		if server.hints.remove(node, key, h.seq) {
+			if sent {
+				server.stats.Add("hints_replayed", 1)
+			} else {
+				server.stats.Add("hints_dropped", 1)
+			}
+		}
+	}
+}
+
+/*
+ * Sends the node our current value or tombstone for `key`. Returns false if we
+ * hold neither any more (it expired, was evicted or its tombstone collected):
+ * we no longer know when the write we hinted for happened, and a delete stamped
+ * now could undo newer writes the node has, so nothing is sent.
+ */
+func (server *KvServerImpl) replayHint(client proto.KvClient, shard int, key string) (bool, error) {
+	var value *proto.GetShardValue
+	server.shardLock.RLock()
+	if server.data == nil {
+		server.shardLock.RUnlock()
+		return false, status.Error(codes.Unavailable, "Server shutting down")
+	}
+	server.locks[shard-1].RLock()
+	now := uint64(time.Now().UnixMilli())
+	if store := server.data[shard-1]; store != nil {
+		if e, exists := store.get(key); exists && e.ttl >= now {
+			value = shardValue(e, now)
+		}
+	}
+	server.locks[shard-1].RUnlock()
+	server.shardLock.RUnlock()
+
+	if value == nil {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hintReplayTimeout)
+	defer cancel()
+	_, err := client.ReplicaWrite(ctx, &proto.ReplicaWriteRequest{Value: value})
+	return err == nil, err
+}
@@ -0,0 +1,48 @@
+package kv
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+ * Hybrid logical clock, which stamps writes for last-writer-wins (see
+ * newerValue). Timestamps are unix nanoseconds whose low hlcLogicalBits bits
+ * hold a logical counter instead, so they stay close to the wall clock (expiry
+ * is computed from them) while every timestamp a clock hands out is later than
+ * the previous one and than every timestamp it has seen, even if its wall
+ * clock is behind the other nodes'.
+ *
+ * Clients stamp the values they write, and servers the writes they accept
+ * without a timestamp. Both observe the timestamps they receive.
+ */
+
+const hlcLogicalBits = 8
+
+type hybridClock struct {
+	mutex sync.Mutex
+	// latest timestamp handed out or seen
+	last uint64
+}
+
+// Returns a new timestamp, later than any the clock handed out or saw before
+func (c *hybridClock) now() uint64 {
+	physical := uint64(time.Now().UnixNano()) &^ (1<<hlcLogicalBits - 1)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if physical > c.last {
+		c.last = physical
+	} else {
+		// the wall clock did not move past the last timestamp: tick the
+		// logical counter (which carries into the physical part on overflow)
+		c.last++
+	}
+	return c.last
+}
+
+// Records a timestamp received from another node, so later ones are after it
+func (c *hybridClock) update(timestamp uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.last = max(c.last, timestamp)
+}
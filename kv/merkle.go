@@ -0,0 +1,84 @@
+package kv
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+/*
+ * Merkle tree over the entries of one shard, used by anti-entropy to find the keys
+ * two replicas disagree on without sending them all (see antientropy.go).
+ *
+ * Keys are placed in one of 2^merkleDepth leaves by the hash of the key, so both
+ * replicas put a key in the same leaf. A leaf hashes the entries in it, and every
+ * inner node hashes its two children: replicas holding the same entries have the
+ * same root, and comparing nodes top-down narrows a difference down to leaves.
+ *
+ * Only what replicas should agree on is hashed: the key, value, write timestamp
+ * and whether it is a tombstone, so deletes are synced too. Versions are counted
+ * per replica, and expiry is left out since its absolute time shifts slightly
+ * whenever it is copied to another node. Expiry changes (Touch, Persist) still
+ * show up, since they give the key a new write timestamp.
+ *
+ * Trees are not maintained as entries change: they are built from a snapshot of
+ * the shard when needed, which costs O(entries) but nothing on the write path.
+ */
+
+const merkleDepth = 10
+
+// Index of the root. The children of node i are 2i and 2i+1, so leaves are
+// numbered from 1<<merkleDepth to (2<<merkleDepth)-1.
+const merkleRoot = 1
+
+type merkleTree [2 << merkleDepth]uint64
+
+// Leaf node holding `key`
+func merkleLeaf(key string) uint32 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+	return 1<<merkleDepth | uint32(hasher.Sum64()>>(64-merkleDepth))
+}
+
+func merkleIsLeaf(node uint32) bool {
+	return node >= 1<<merkleDepth
+}
+
+func entryHash(e *entry) uint64 {
+	hasher := fnv.New64a()
+	var buf [binary.MaxVarintLen64]byte
+	hasher.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.key)))])
+	hasher.Write([]byte(e.key))
+	hasher.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.value)))])
+	hasher.Write([]byte(e.value))
+	hasher.Write(buf[:binary.PutUvarint(buf[:], e.timestamp)])
+	if e.deleted {
+		hasher.Write([]byte{1})
+	}
+	return hasher.Sum64()
+}
+
+// Builds the tree of the entries (values and tombstones) which have not expired at `now` (in ms)
+func buildMerkleTree(entries shardSnapshot, now uint64) *merkleTree {
+	tree := &merkleTree{}
+	entries.iterate(func(e *entry) bool {
+		if e.ttl >= now {
+			// entries are unique per key, so the order they are summed in does not matter
+			tree[merkleLeaf(e.key)] += entryHash(e)
+		}
+		return true
+	})
+	var buf [16]byte
+	for node := uint32(1<<merkleDepth) - 1; node >= merkleRoot; node-- {
+		left, right := tree[2*node], tree[2*node+1]
+		if left == 0 && right == 0 {
+			// empty subtree
+			continue
+		}
+		binary.LittleEndian.PutUint64(buf[:8], left)
+		binary.LittleEndian.PutUint64(buf[8:], right)
+		hasher := fnv.New64a()
+		hasher.Write(buf[:])
+		tree[node] = hasher.Sum64()
+	}
+	return tree
+}
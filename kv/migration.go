@@ -0,0 +1,279 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Status of shard copies, for operators (see KvAdminImpl). Every copy of a
+ * shard, whether started by a ShardMap update (see receiveShard) or by
+ * RetryShardCopy, is tracked as a shardMigration which replaces the shard's
+ * previous one, so GetMigrationStatus reports the latest copy of each shard;
+ * shards which were never copied are ACTIVE. A shard's migration is forgotten
+ * once the shard moves off the node.
+ *
+ * Copies which fail leave the shard served with whatever it held (FAILED), and
+ * can be retried in the background, merged under the shard's current contents
+ * like any copy (see copyShardFrom).
+ */
+
+type shardMigration struct {
+	// guarded by shardMigrations.mutex
+	state     proto.ShardMigrationStatus_State
+	source    string
+	keys      int64
+	bytes     int64
+	startTime time.Time
+	err       string
+
+	// stops the copy
+	cancel context.CancelFunc
+}
+
+type shardMigrations struct {
+	mutex  sync.Mutex
+	shards map[int]*shardMigration
+	// set on shutdown: copies begun after it are cancelled from the start
+	stopped bool
+}
+
+/*
+ * Starts tracking a new copy of `shard`, returning the context it must run
+ * under. Fails if a copy of the shard is running already, unless `replace` is
+ * set, in which case that one is cancelled.
+ */
+func (m *shardMigrations) begin(shard int, replace bool) (*shardMigration, context.Context, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if current := m.shards[shard]; current != nil && current.state == proto.ShardMigrationStatus_COPYING {
+		if !replace {
+			return nil, nil, false
+		}
+		current.cancel()
+	}
+	if m.shards == nil {
+		m.shards = make(map[int]*shardMigration)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if m.stopped {
+		cancel()
+	}
+	migration := &shardMigration{state: proto.ShardMigrationStatus_COPYING, cancel: cancel}
+	m.shards[shard] = migration
+	return migration, ctx, true
+}
+
+// Records that the copy (re)starts from `node`
+func (m *shardMigrations) copyFrom(migration *shardMigration, node string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	migration.source = node
+	migration.keys = 0
+	migration.bytes = 0
+	migration.startTime = time.Now()
+	migration.err = ""
+}
+
+// Records entries received by the copy
+func (m *shardMigrations) copied(migration *shardMigration, keys int64, bytes int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	migration.keys += keys
+	migration.bytes += bytes
+}
+
+func (m *shardMigrations) finish(migration *shardMigration, state proto.ShardMigrationStatus_State, err string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	migration.state = state
+	migration.err = err
+	migration.cancel()
+}
+
+// Cancels the running copy of `shard`. Returns false if there is none.
+func (m *shardMigrations) cancel(shard int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	current := m.shards[shard]
+	if current == nil || current.state != proto.ShardMigrationStatus_COPYING {
+		return false
+	}
+	current.cancel()
+	return true
+}
+
+// Cancels any copy of `shard` and drops its status, once the shard moved off the node
+func (m *shardMigrations) forget(shard int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if current := m.shards[shard]; current != nil {
+		current.cancel()
+		delete(m.shards, shard)
+	}
+}
+
+// Cancels every running copy, and any begun later, on shutdown
+func (m *shardMigrations) stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stopped = true
+	for _, migration := range m.shards {
+		migration.cancel()
+	}
+}
+
+func (m *shardMigrations) status(shard int) *proto.ShardMigrationStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	shardStatus := &proto.ShardMigrationStatus{Shard: int32(shard)}
+	if migration := m.shards[shard]; migration != nil {
+		shardStatus.State = migration.state
+		shardStatus.Source = migration.source
+		shardStatus.KeysCopied = migration.keys
+		shardStatus.BytesCopied = migration.bytes
+		shardStatus.Error = migration.err
+		if !migration.startTime.IsZero() {
+			shardStatus.StartTimeMs = migration.startTime.UnixMilli()
+		}
+	}
+	return shardStatus
+}
+
+// `nodes` in order starting at a random one, so copies spread over the replicas
+func fromRandomNode(nodes []string) []string {
+	if len(nodes) == 0 {
+		return nodes
+	}
+	start := rand.Intn(len(nodes))
+	return append(append(make([]string, 0, len(nodes)), nodes[start:]...), nodes[:start]...)
+}
+
+/*
+ * Copies `shard` from the first of `sources` (other than us) which can give
+ * it, each attempt bounded by the shard copy timeout, and records how it went
+ * in `migration`. Returns the state the copy ended in.
+ */
+func (server *KvServerImpl) copyShard(ctx context.Context, migration *shardMigration, shard int, sources []string) proto.ShardMigrationStatus_State {
+	var lastErr error
+	for _, node := range sources {
+		if node == server.nodeName {
+			continue
+		}
+		server.migrations.copyFrom(migration, node)
+		copyCtx, cancel := context.WithTimeout(ctx, server.shardCopyTimeout)
+		err := server.copyShardFrom(copyCtx, shard, node, migration)
+		cancel()
+		if err == nil {
+			server.stats.Add("shards_copied", 1)
+			server.migrations.finish(migration, proto.ShardMigrationStatus_ACTIVE, "")
+			return proto.ShardMigrationStatus_ACTIVE
+		}
+		logrus.Debugln("(copyShard): copying shard ", shard, " from ", node, " failed: ", err)
+		server.stats.Add("shard_copy_failures", 1)
+		lastErr = err
+		if ctx.Err() != nil {
+			lastErr = errors.New("copy cancelled")
+			break
+		}
+	}
+	if lastErr == nil {
+		server.migrations.finish(migration, proto.ShardMigrationStatus_NO_PEER, "")
+		return proto.ShardMigrationStatus_NO_PEER
+	}
+	server.migrations.finish(migration, proto.ShardMigrationStatus_FAILED, lastErr.Error())
+	return proto.ShardMigrationStatus_FAILED
+}
+
+/*
+ * Serves the KvAdmin service of a node: calls for operators, which are kept out
+ * of the Kv service so clients cannot make them.
+ */
+type KvAdminImpl struct {
+	proto.UnimplementedKvAdminServer
+	server *KvServerImpl
+}
+
+func MakeKvAdmin(server *KvServerImpl) *KvAdminImpl {
+	return &KvAdminImpl{server: server}
+}
+
+func (admin *KvAdminImpl) GetMigrationStatus(
+	ctx context.Context,
+	request *proto.GetMigrationStatusRequest,
+) (*proto.GetMigrationStatusResponse, error) {
+	server := admin.server
+	server.shardLock.RLock()
+	shards := make([]int, 0, len(server.hostedShards)+len(server.receivingShards))
+	for shard := range server.hostedShards {
+		shards = append(shards, shard)
+	}
+	for shard := range server.receivingShards {
+		if !server.hostedShards[shard] {
+			shards = append(shards, shard)
+		}
+	}
+	server.shardLock.RUnlock()
+	sort.Ints(shards)
+
+	response := &proto.GetMigrationStatusResponse{Shards: make([]*proto.ShardMigrationStatus, 0, len(shards))}
+	for _, shard := range shards {
+		response.Shards = append(response.Shards, server.migrations.status(shard))
+	}
+	return response, nil
+}
+
+func (admin *KvAdminImpl) CancelShardCopy(
+	ctx context.Context,
+	request *proto.CancelShardCopyRequest,
+) (*proto.CancelShardCopyResponse, error) {
+	if !admin.server.migrations.cancel(int(request.Shard)) {
+		return nil, status.Errorf(codes.FailedPrecondition, "no copy of shard %d is running", request.Shard)
+	}
+	return &proto.CancelShardCopyResponse{}, nil
+}
+
+func (admin *KvAdminImpl) RetryShardCopy(
+	ctx context.Context,
+	request *proto.RetryShardCopyRequest,
+) (*proto.RetryShardCopyResponse, error) {
+	server := admin.server
+	if server.raft != nil {
+		return nil, status.Error(codes.FailedPrecondition, "shards are copied by their Raft groups")
+	}
+	shard := int(request.Shard)
+	if request.Source == server.nodeName {
+		return nil, status.Error(codes.InvalidArgument, "cannot copy a shard from its own node")
+	}
+	sources := []string{request.Source}
+	if request.Source == "" {
+		sources = fromRandomNode(server.shardMap.NodesForShard(shard))
+	}
+
+	// handleShardMapUpdate drops a shard from hostedShards under shardLock
+	// before forgetting (and cancelling) its migration: holding the lock from
+	// the check until the copy is tracked, either we see the shard is gone or
+	// the move sees the copy, which cannot refill the shard once it moved off.
+	server.shardLock.RLock()
+	if !server.hostedShards[shard] {
+		server.shardLock.RUnlock()
+		return nil, status.Errorf(codes.NotFound, "shard %d is not hosted by this node", shard)
+	}
+	migration, copyCtx, ok := server.migrations.begin(shard, false)
+	server.shardLock.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "shard %d is already being copied", shard)
+	}
+	server.stats.Add("shard_copy_retries", 1)
+	go server.copyShard(copyCtx, migration, shard, sources)
+	return &proto.RetryShardCopyResponse{}, nil
+}
@@ -0,0 +1,603 @@
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+ * On-disk persistence for KvServerImpl.
+ *
+ * Every Set/Delete is appended to a per-node write-ahead log (WAL) before it is
+ * applied in memory. Periodically we checkpoint: the WAL is rotated to a fresh
+ * segment, every hosted shard is written to its own snapshot file, and the older
+ * segments are removed since the snapshots now cover them.
+ *
+ * Every record carries a log sequence number (LSN). Records are appended while
+ * holding the lock of the shard they modify, so a snapshot taken under the shard
+ * lock contains exactly the records for that shard with LSN <= the snapshot's LSN.
+ * On startup we load the snapshots and then replay the remaining WAL records on top.
+ *
+ * Layout of the data directory:
+ *   wal-<first lsn>.log   WAL segments, replayed in LSN order
+ *   shard-<n>.snap        latest snapshot for shard n
+ *
+ * Both file types are made of CRC-checked frames, so a torn write at the tail of the
+ * WAL (e.g. from a crash mid-append) is detected on replay. It is cut off before we
+ * append again, since records written after it would be unreadable.
+ */
+
+type walOp uint8
+
+const (
+	walSet walOp = iota + 1
+	walDelete
+	walClearShard
+	// like walSet, for a tombstone
+	walTombstone
+)
+
+type walRecord struct {
+	lsn       uint64
+	op        walOp
+	shard     int
+	key       string
+	value     string
+	ttl       uint64
+	version   uint64
+	timestamp uint64
+}
+
+// Record for writing `e` to `shard`
+func setRecord(shard int, e *entry) *walRecord {
+	op := walSet
+	if e.deleted {
+		op = walTombstone
+	}
+	return &walRecord{op: op, shard: shard, key: e.key, value: e.value, ttl: e.ttl, version: e.version, timestamp: e.timestamp}
+}
+
+// Entry written by a walSet or walTombstone record
+func (record *walRecord) entry() *entry {
+	return &entry{key: record.key, value: record.value, ttl: record.ttl, version: record.version, timestamp: record.timestamp, deleted: record.op == walTombstone}
+}
+
+var errCorruptFrame = errors.New("corrupt frame")
+
+const (
+	walPrefix      = "wal-"
+	walSuffix      = ".log"
+	snapshotPrefix = "shard-"
+	snapshotSuffix = ".snap"
+)
+
+type persistence struct {
+	dir string
+
+	// mutex protects the current WAL segment and the LSN counter
+	mutex   sync.Mutex
+	wal     *os.File
+	nextLsn uint64
+	dirty   bool
+}
+
+func openPersistence(dir string) (*persistence, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &persistence{dir: dir, nextLsn: 1}, nil
+}
+
+func (p *persistence) segmentPath(firstLsn uint64) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%s%020d%s", walPrefix, firstLsn, walSuffix))
+}
+
+func (p *persistence) snapshotPath(shard int) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%s%d%s", snapshotPrefix, shard, snapshotSuffix))
+}
+
+/*
+ * Returns the existing WAL segments sorted by the first LSN they contain.
+ */
+func (p *persistence) segments() ([]uint64, error) {
+	files, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	starts := make([]uint64, 0)
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, walPrefix) || !strings.HasSuffix(name, walSuffix) {
+			continue
+		}
+		var start uint64
+		_, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, walPrefix), walSuffix), "%d", &start)
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts, nil
+}
+
+/*
+ * Rebuilds the contents of every shard from the snapshots and the WAL, and opens a
+ * new WAL segment for further writes. Entries whose TTL has passed are dropped.
+ *
+ * Must be called exactly once, before any log* method.
+ */
+func (p *persistence) recover(numShards int) ([]map[string]*entry, error) {
+	data := make([]map[string]*entry, numShards)
+	snapshotLsns := make([]uint64, numShards)
+	maxLsn := uint64(0)
+
+	for shard := 1; shard <= numShards; shard++ {
+		lsn, entries, err := p.readSnapshot(shard)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot for shard %d: %w", shard, err)
+		}
+		data[shard-1] = entries
+		snapshotLsns[shard-1] = lsn
+		if lsn > maxLsn {
+			maxLsn = lsn
+		}
+	}
+
+	segments, err := p.segments()
+	if err != nil {
+		return nil, err
+	}
+	replayed := 0
+	for _, start := range segments {
+		valid, torn, err := p.readSegment(start, func(record *walRecord) {
+			if record.lsn > maxLsn {
+				maxLsn = record.lsn
+			}
+			if record.shard < 1 || record.shard > numShards || record.lsn <= snapshotLsns[record.shard-1] {
+				return
+			}
+			replayed++
+			shardData := data[record.shard-1]
+			switch record.op {
+			case walSet, walTombstone:
+				if shardData == nil {
+					shardData = make(map[string]*entry)
+					data[record.shard-1] = shardData
+				}
+				shardData[record.key] = record.entry()
+			case walDelete:
+				delete(shardData, record.key)
+			case walClearShard:
+				data[record.shard-1] = nil
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if torn {
+			// the segment we append to next may be this one (or a torn file
+			// holding no record at all, named after the next LSN)
+			logrus.WithFields(logrus.Fields{"segment": start, "bytes": valid}).Warn("truncating torn WAL tail")
+			if err := os.Truncate(p.segmentPath(start), valid); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := uint64(time.Now().UnixMilli())
+	for i := range data {
+		for key, e := range data[i] {
+			if e.ttl < now {
+				delete(data[i], key)
+			}
+		}
+	}
+
+	p.nextLsn = maxLsn + 1
+	wal, err := os.OpenFile(p.segmentPath(p.nextLsn), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	p.wal = wal
+	logrus.WithFields(logrus.Fields{"dir": p.dir, "segments": len(segments), "replayed": replayed}).Info("recovered data from disk")
+	return data, nil
+}
+
+/*
+ * Reads every well-formed record in a segment. Reading stops at the first corrupt
+ * or truncated frame, which can only be the result of a torn write at the tail.
+ * Returns the length of the well-formed records, and whether anything follows
+ * them.
+ */
+func (p *persistence) readSegment(start uint64, apply func(*walRecord)) (int64, bool, error) {
+	file, err := os.Open(p.segmentPath(start))
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	valid := int64(0)
+	for {
+		payload, err := readFrame(reader)
+		if err == io.EOF {
+			return valid, false, nil
+		}
+		if err != nil {
+			logrus.WithField("segment", start).Warnf("ignoring WAL tail: %q", err)
+			return valid, true, nil
+		}
+		record, err := decodeWalRecord(payload)
+		if err != nil {
+			logrus.WithField("segment", start).Warnf("ignoring WAL tail: %q", err)
+			return valid, true, nil
+		}
+		apply(record)
+		valid += int64(frameHeaderBytes + len(payload))
+	}
+}
+
+func (p *persistence) append(record *walRecord) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return errors.New("write-ahead log is closed")
+	}
+	record.lsn = p.nextLsn
+	if _, err := p.wal.Write(encodeFrame(encodeWalRecord(record))); err != nil {
+		return err
+	}
+	p.nextLsn++
+	p.dirty = true
+	return nil
+}
+
+// NOTE: the log* methods must be called while holding the lock for `shard`
+func (p *persistence) logSet(shard int, e *entry) error {
+	return p.append(setRecord(shard, e))
+}
+
+func (p *persistence) logDelete(shard int, key string) error {
+	return p.append(&walRecord{op: walDelete, shard: shard, key: key})
+}
+
+func (p *persistence) logClearShard(shard int) error {
+	return p.append(&walRecord{op: walClearShard, shard: shard})
+}
+
+/*
+ * LSN of the last record appended. Records appended afterwards have a larger LSN.
+ */
+func (p *persistence) lastLsn() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.nextLsn - 1
+}
+
+/*
+ * Flushes the current WAL segment to stable storage if anything was appended
+ * since the last sync.
+ */
+func (p *persistence) sync() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil || !p.dirty {
+		return nil
+	}
+	p.dirty = false
+	return p.wal.Sync()
+}
+
+/*
+ * Starts a new WAL segment. Returns the first LSN of the new segment: every record
+ * in older segments has a smaller LSN.
+ */
+func (p *persistence) rotate() (uint64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return 0, errors.New("write-ahead log is closed")
+	}
+	wal, err := os.OpenFile(p.segmentPath(p.nextLsn), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.wal.Sync(); err != nil {
+		wal.Close()
+		return 0, err
+	}
+	p.wal.Close()
+	p.wal = wal
+	p.dirty = false
+	return p.nextLsn, nil
+}
+
+/*
+ * Removes every segment which only contains records older than `lsn`.
+ */
+func (p *persistence) removeSegmentsBefore(lsn uint64) error {
+	segments, err := p.segments()
+	if err != nil {
+		return err
+	}
+	for i, start := range segments {
+		// a segment ends right before the next one starts
+		if i+1 < len(segments) && segments[i+1] <= lsn {
+			if err := os.Remove(p.segmentPath(start)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+ * Atomically replaces the snapshot for `shard` (write to a temporary file, then rename).
+ */
+func (p *persistence) writeSnapshot(shard int, lsn uint64, entries shardSnapshot) error {
+	path := p.snapshotPath(shard)
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	header := binary.AppendUvarint(nil, lsn)
+	header = binary.AppendUvarint(header, uint64(entries.size()))
+	_, err = writer.Write(encodeFrame(header))
+	if err == nil {
+		entries.iterate(func(e *entry) bool {
+			_, err = writer.Write(encodeFrame(encodeWalRecord(setRecord(shard, e))))
+			return err == nil
+		})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (p *persistence) removeSnapshot(shard int) error {
+	err := os.Remove(p.snapshotPath(shard))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (p *persistence) readSnapshot(shard int) (uint64, map[string]*entry, error) {
+	file, err := os.Open(p.snapshotPath(shard))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	header, err := readFrame(reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	headerReader := bytes.NewReader(header)
+	lsn, err := binary.ReadUvarint(headerReader)
+	if err != nil {
+		return 0, nil, err
+	}
+	count, err := binary.ReadUvarint(headerReader)
+	if err != nil {
+		return 0, nil, err
+	}
+	// unlike the WAL, snapshots are written atomically so any corruption is an error
+	entries := make(map[string]*entry, count)
+	for i := uint64(0); i < count; i++ {
+		payload, err := readFrame(reader)
+		if err != nil {
+			return 0, nil, err
+		}
+		record, err := decodeWalRecord(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		entries[record.key] = record.entry()
+	}
+	return lsn, entries, nil
+}
+
+func (p *persistence) close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return nil
+	}
+	err := p.wal.Sync()
+	p.wal.Close()
+	p.wal = nil
+	return err
+}
+
+/*
+ * Frames are [4 byte length][4 byte CRC32 of payload][payload]. Keys and values are
+ * written as raw length-prefixed bytes so that arbitrary (binary) data round-trips.
+ */
+// Length and CRC of the payload
+const frameHeaderBytes = 8
+
+func encodeFrame(payload []byte) []byte {
+	frame := make([]byte, frameHeaderBytes, frameHeaderBytes+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	return append(frame, payload...)
+}
+
+func readFrame(reader io.Reader) ([]byte, error) {
+	header := make([]byte, frameHeaderBytes)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errCorruptFrame
+		}
+		return nil, err
+	}
+	payload := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, errCorruptFrame
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, errCorruptFrame
+	}
+	return payload, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(reader *bytes.Reader) (string, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(reader.Len()) {
+		return "", errCorruptFrame
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func encodeWalRecord(record *walRecord) []byte {
+	buf := binary.AppendUvarint(nil, record.lsn)
+	buf = append(buf, byte(record.op))
+	buf = binary.AppendUvarint(buf, uint64(record.shard))
+	buf = appendString(buf, record.key)
+	buf = appendString(buf, record.value)
+	buf = binary.AppendUvarint(buf, record.ttl)
+	buf = binary.AppendUvarint(buf, record.version)
+	return binary.AppendUvarint(buf, record.timestamp)
+}
+
+func decodeWalRecord(payload []byte) (*walRecord, error) {
+	reader := bytes.NewReader(payload)
+	record := &walRecord{}
+	var err error
+	if record.lsn, err = binary.ReadUvarint(reader); err != nil {
+		return nil, err
+	}
+	op, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	record.op = walOp(op)
+	shard, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+	record.shard = int(shard)
+	if record.key, err = readString(reader); err != nil {
+		return nil, err
+	}
+	if record.value, err = readString(reader); err != nil {
+		return nil, err
+	}
+	if record.ttl, err = binary.ReadUvarint(reader); err != nil {
+		return nil, err
+	}
+	if record.version, err = binary.ReadUvarint(reader); err != nil {
+		return nil, err
+	}
+	// absent from records written before timestamps existed
+	if reader.Len() > 0 {
+		if record.timestamp, err = binary.ReadUvarint(reader); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+/*
+ * Rotates the WAL and snapshots every shard, then drops the WAL segments the
+ * snapshots made redundant.
+ */
+func (server *KvServerImpl) checkpoint() error {
+	p := server.persistence
+	rotatedAt, err := p.rotate()
+	if err != nil {
+		return err
+	}
+	for shard := 1; shard <= server.shardMap.NumShards(); shard++ {
+		server.shardLock.RLock()
+		if server.data == nil {
+			// shutting down
+			server.shardLock.RUnlock()
+			return nil
+		}
+		server.locks[shard-1].Lock()
+		lsn := p.lastLsn()
+		hasData := server.data[shard-1] != nil
+		var snapshot shardSnapshot
+		if hasData {
+			snapshot = server.data[shard-1].snapshot()
+		}
+		server.locks[shard-1].Unlock()
+		server.shardLock.RUnlock()
+
+		if hasData {
+			err = p.writeSnapshot(shard, lsn, snapshot)
+		} else {
+			err = p.removeSnapshot(shard)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return p.removeSegmentsBefore(rotatedAt)
+}
+
+/*
+ * Background loop which periodically fsyncs the WAL and takes checkpoints.
+ */
+func (server *KvServerImpl) persistenceLoop(snapshotInterval time.Duration) {
+	defer close(server.persistenceDone)
+	syncTick := time.NewTicker(time.Second)
+	defer syncTick.Stop()
+	snapshotTick := time.NewTicker(snapshotInterval)
+	defer snapshotTick.Stop()
+	for {
+		select {
+		case <-server.shutdown:
+			return
+		case <-syncTick.C:
+			if err := server.persistence.sync(); err != nil {
+				logrus.WithField("node", server.nodeName).Errorf("failed to sync WAL: %q", err)
+			}
+		case <-snapshotTick.C:
+			if err := server.checkpoint(); err != nil {
+				logrus.WithField("node", server.nodeName).Errorf("failed to checkpoint: %q", err)
+			}
+		}
+	}
+}
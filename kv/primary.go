@@ -0,0 +1,241 @@
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Primary-backup replication (KvServerOptions.PrimaryBackup). The first node
+ * listed for a shard in the ShardMap is its primary, and clients send it every
+ * write to the shard (see KvOptions.PrimaryBackup). The other nodes, the
+ * backups, reject client writes with a FailedPrecondition error carrying a
+ * NotPrimary detail, which names the primary.
+ *
+ * The primary applies each write locally, then sends the resulting state of the
+ * key to every backup (BackupWrite) and only acks the client once they all
+ * applied it. Writes to the same key are ordered by a per-key lock held until
+ * the backups answered, so every replica sees them in the same order, and any
+ * kind of write (CompareAndSet, Increment, Touch...) replicates the same way.
+ *
+ * Hand-off when the ShardMap changes the primary: backups only accept writes
+ * from the node which is the primary in their own ShardMap, and check it while
+ * holding the lock for the key. A write the old primary has in flight either
+ * reaches a backup before it switched (and is ordered before anything the new
+ * primary writes to the key), or is rejected and fails. Acked writes thus
+ * reached every replica, including the new primary.
+ *
+ * A write which fails may still have been applied on the primary and some
+ * backups, as with the client writing to every replica itself.
+ */
+
+// how long the primary waits for a backup to apply one write
+const backupWriteTimeout = time.Second
+
+/*
+ * Exclusive locks on individual keys. Unlike striping keys over a fixed set of
+ * locks, unrelated keys never wait on each other, so a primary holding the lock
+ * for a key while calling a backup cannot deadlock with that node doing the same
+ * for another key of a shard it is the primary of.
+ *
+ * Waits for a lock give up when their context is done: while the ShardMap
+ * changes, two nodes may each hold the lock for the same key while calling the
+ * other, and the calls' timeouts break the cycle.
+ */
+type keyLocks struct {
+	mutex sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	// holds a value while the lock is held
+	held chan struct{}
+	// goroutines holding or waiting for the lock, so it is freed once unused
+	users int
+}
+
+// Locks `key`, returning the function which unlocks it. Fails if ctx is done first.
+func (l *keyLocks) lock(ctx context.Context, key string) (func(), error) {
+	l.mutex.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	lock, exists := l.locks[key]
+	if !exists {
+		lock = &keyLock{held: make(chan struct{}, 1)}
+		l.locks[key] = lock
+	}
+	lock.users++
+	l.mutex.Unlock()
+
+	release := func() {
+		l.mutex.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(l.locks, key)
+		}
+		l.mutex.Unlock()
+	}
+	select {
+	case lock.held <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	return func() {
+		<-lock.held
+		release()
+	}, nil
+}
+
+// Primary of `shard` in our ShardMap, "" if it has no nodes
+func (server *KvServerImpl) primaryOf(shard int) string {
+	nodes := server.shardMap.NodesForShard(shard)
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodes[0]
+}
+
+// The error a node returns for a client write to a shard it is not the primary of
+func notPrimaryError(shard int, primary string) error {
+	s := status.Newf(codes.FailedPrecondition, "not the primary of shard %d (primary is %q)", shard, primary)
+	withDetails, err := s.WithDetails(&proto.NotPrimary{Shard: int32(shard), Primary: primary})
+	if err != nil {
+		return s.Err()
+	}
+	return withDetails.Err()
+}
+
+/*
+ * Runs `apply`, which changes `key` in `shard` (taking the shard lock itself),
+ * on behalf of a client. In primary-backup mode, fails unless we are the shard's
+ * primary, and copies the result to the backups before returning. Likewise in
+ * chain replication mode for the head of the chain, which passes the result down
+ * the chain (see chain.go).
+ */
+func (server *KvServerImpl) clientWrite(ctx context.Context, shard int, key string, apply func() error) error {
+	if !server.primaryBackup && !server.chainReplication {
+		return apply()
+	}
+	unlock, err := server.keyLocks.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if primary := server.primaryOf(shard); primary != server.nodeName {
+		server.stats.Add("primary_redirects", 1)
+		return notPrimaryError(shard, primary)
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if server.chainReplication {
+		return server.passDownChain(ctx, shard, key)
+	}
+	return server.replicateToBackups(ctx, shard, key)
+}
+
+// Current state of `key` in `shard`, to copy to another replica: nil if it has
+// neither a live value nor a tombstone
+func (server *KvServerImpl) keyState(shard int, key string) *proto.GetShardValue {
+	server.locks[shard-1].RLock()
+	defer server.locks[shard-1].RUnlock()
+	now := uint64(time.Now().UnixMilli())
+	if store := server.data[shard-1]; store != nil {
+		if e, exists := store.get(key); exists && e.ttl >= now {
+			return shardValue(e, now)
+		}
+	}
+	return nil
+}
+
+// Makes `value` (from keyState on another replica) the state of `key` in `shard`
+func (server *KvServerImpl) storeKeyState(shard int, key string, value *proto.GetShardValue) error {
+	server.locks[shard-1].Lock()
+	defer server.locks[shard-1].Unlock()
+	if server.data[shard-1] == nil {
+		return status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	if value == nil || (!value.NoExpiry && value.TtlMsRemaining <= 0) {
+		return server.dropKey(shard, key)
+	}
+	return server.storeEntry(shard, entryFromShardValue(value, uint64(time.Now().UnixMilli())))
+}
+
+// Sends the current state of `key` to every backup of `shard`, in parallel
+func (server *KvServerImpl) replicateToBackups(ctx context.Context, shard int, key string) error {
+	request := &proto.BackupWriteRequest{Primary: server.nodeName, Key: []byte(key), Value: server.keyState(shard, key)}
+
+	// the write is applied here already: let the backups get it even if the
+	// client gives up
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backupWriteTimeout)
+	defer cancel()
+
+	nodes := server.shardMap.NodesForShard(shard)
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		if node == server.nodeName {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			client, err := server.clientPool.GetClient(node)
+			if err == nil {
+				_, err = client.BackupWrite(ctx, request)
+			}
+			if err != nil {
+				errs[i] = status.Errorf(status.Code(err), "failed to replicate to backup %s: %s", node, status.Convert(err).Message())
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			server.stats.Add("backup_write_failures", 1)
+			return err
+		}
+	}
+	return nil
+}
+
+func (server *KvServerImpl) BackupWrite(
+	ctx context.Context,
+	request *proto.BackupWriteRequest,
+) (*proto.BackupWriteResponse, error) {
+	if err := server.rejectInRaftMode(); err != nil {
+		return nil, err
+	}
+	if len(request.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	key := string(request.Key)
+	shard, err := server.checkShardWritable(key)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := server.keyLocks.lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if primary := server.primaryOf(shard); primary != request.Primary || primary == server.nodeName {
+		return nil, status.Errorf(codes.FailedPrecondition, "%q is not the primary of shard %d", request.Primary, shard)
+	}
+
+	if err := server.storeKeyState(shard, key, request.Value); err != nil {
+		return nil, err
+	}
+	server.stats.Add("backup_writes_applied", 1)
+	return &proto.BackupWriteResponse{}, nil
+}
@@ -21,7 +21,58 @@ type KvClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error)
+	// Same as Get/Set/Delete, for keys and values which are not valid UTF-8. Both
+	// families of calls operate on the same data.
+	GetBytes(ctx context.Context, in *GetBytesRequest, opts ...grpc.CallOption) (*GetBytesResponse, error)
+	SetBytes(ctx context.Context, in *SetBytesRequest, opts ...grpc.CallOption) (*SetBytesResponse, error)
+	DeleteBytes(ctx context.Context, in *DeleteBytesRequest, opts ...grpc.CallOption) (*DeleteBytesResponse, error)
+	// Inspect or change the expiry of a key without touching its value or version.
+	GetTTL(ctx context.Context, in *GetTTLRequest, opts ...grpc.CallOption) (*GetTTLResponse, error)
+	Touch(ctx context.Context, in *TouchRequest, opts ...grpc.CallOption) (*TouchResponse, error)
+	// Makes a key never expire (until its next Set or Touch).
+	Persist(ctx context.Context, in *PersistRequest, opts ...grpc.CallOption) (*PersistResponse, error)
+	// Atomically add to (or subtract from) a value holding a decimal int64.
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
+	Decrement(ctx context.Context, in *DecrementRequest, opts ...grpc.CallOption) (*DecrementResponse, error)
+	// Batched versions of Get/Set/Delete. Keys may belong to different shards;
+	// keys the node does not host fail individually, not the whole batch.
+	MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error)
+	MultiSet(ctx context.Context, in *MultiSetRequest, opts ...grpc.CallOption) (*MultiSetResponse, error)
+	MultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*MultiDeleteResponse, error)
 	GetShardContents(ctx context.Context, in *GetShardContentsRequest, opts ...grpc.CallOption) (*GetShardContentsResponse, error)
+	// Same contents as GetShardContents, but sent as a series of bounded chunks.
+	StreamShardContents(ctx context.Context, in *StreamShardContentsRequest, opts ...grpc.CallOption) (Kv_StreamShardContentsClient, error)
+	// Lists the keys of one shard in order, one page at a time.
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	// Streams changes to a key (or to keys with a prefix) in one shard, until the
+	// client cancels, the shard is moved off this node, or the client falls too
+	// far behind. Headers are sent once the subscription is active.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kv_WatchClient, error)
+	// Stores a value which another replica accepted, keeping its version, expiry
+	// and timestamp, unless the node has a newer one. Used by read repair.
+	ReplicaWrite(ctx context.Context, in *ReplicaWriteRequest, opts ...grpc.CallOption) (*ReplicaWriteResponse, error)
+	// Asks a node which applied a write to forward the key to another replica
+	// which missed it, once that one is reachable again (hinted handoff). Fails
+	// with ResourceExhausted if the node holds too many hints already.
+	StoreHint(ctx context.Context, in *StoreHintRequest, opts ...grpc.CallOption) (*StoreHintResponse, error)
+	// Anti-entropy between replicas: hashes of nodes of a shard's Merkle tree,
+	// and the entries under some of its leaves.
+	GetMerkleHashes(ctx context.Context, in *MerkleHashesRequest, opts ...grpc.CallOption) (*MerkleHashesResponse, error)
+	GetMerkleLeaves(ctx context.Context, in *MerkleLeavesRequest, opts ...grpc.CallOption) (*MerkleLeavesResponse, error)
+	// Applies a write on a backup of a shard, on behalf of its primary (see
+	// BackupWriteRequest). Fails with FailedPrecondition if the sender is not
+	// the primary according to the node.
+	BackupWrite(ctx context.Context, in *BackupWriteRequest, opts ...grpc.CallOption) (*BackupWriteResponse, error)
+	// Applies a write on a node of a shard's chain, and passes it on down the
+	// chain, returning once the tail applied it (see ChainWriteRequest). Fails
+	// with FailedPrecondition if the sender is not the node's predecessor.
+	ChainWrite(ctx context.Context, in *ChainWriteRequest, opts ...grpc.CallOption) (*ChainWriteResponse, error)
+	// Raft mode: the RPCs between the members of a shard's Raft group.
+	RaftRequestVote(ctx context.Context, in *RaftRequestVoteRequest, opts ...grpc.CallOption) (*RaftRequestVoteResponse, error)
+	RaftAppendEntries(ctx context.Context, in *RaftAppendEntriesRequest, opts ...grpc.CallOption) (*RaftAppendEntriesResponse, error)
+	// Node-level counters such as evictions, for operators and tests.
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 }
 
 type kvClient struct {
@@ -59,6 +110,114 @@ func (c *kvClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.C
 	return out, nil
 }
 
+func (c *kvClient) CompareAndSet(ctx context.Context, in *CompareAndSetRequest, opts ...grpc.CallOption) (*CompareAndSetResponse, error) {
+	out := new(CompareAndSetResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/CompareAndSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) GetBytes(ctx context.Context, in *GetBytesRequest, opts ...grpc.CallOption) (*GetBytesResponse, error) {
+	out := new(GetBytesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/GetBytes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) SetBytes(ctx context.Context, in *SetBytesRequest, opts ...grpc.CallOption) (*SetBytesResponse, error) {
+	out := new(SetBytesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/SetBytes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) DeleteBytes(ctx context.Context, in *DeleteBytesRequest, opts ...grpc.CallOption) (*DeleteBytesResponse, error) {
+	out := new(DeleteBytesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/DeleteBytes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) GetTTL(ctx context.Context, in *GetTTLRequest, opts ...grpc.CallOption) (*GetTTLResponse, error) {
+	out := new(GetTTLResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/GetTTL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) Touch(ctx context.Context, in *TouchRequest, opts ...grpc.CallOption) (*TouchResponse, error) {
+	out := new(TouchResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/Touch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) Persist(ctx context.Context, in *PersistRequest, opts ...grpc.CallOption) (*PersistResponse, error) {
+	out := new(PersistResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/Persist", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error) {
+	out := new(IncrementResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/Increment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) Decrement(ctx context.Context, in *DecrementRequest, opts ...grpc.CallOption) (*DecrementResponse, error) {
+	out := new(DecrementResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/Decrement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error) {
+	out := new(MultiGetResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/MultiGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) MultiSet(ctx context.Context, in *MultiSetRequest, opts ...grpc.CallOption) (*MultiSetResponse, error) {
+	out := new(MultiSetResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/MultiSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) MultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*MultiDeleteResponse, error) {
+	out := new(MultiDeleteResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/MultiDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kvClient) GetShardContents(ctx context.Context, in *GetShardContentsRequest, opts ...grpc.CallOption) (*GetShardContentsResponse, error) {
 	out := new(GetShardContentsResponse)
 	err := c.cc.Invoke(ctx, "/kv.Kv/GetShardContents", in, out, opts...)
@@ -68,6 +227,160 @@ func (c *kvClient) GetShardContents(ctx context.Context, in *GetShardContentsReq
 	return out, nil
 }
 
+func (c *kvClient) StreamShardContents(ctx context.Context, in *StreamShardContentsRequest, opts ...grpc.CallOption) (Kv_StreamShardContentsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Kv_ServiceDesc.Streams[0], "/kv.Kv/StreamShardContents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kvStreamShardContentsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Kv_StreamShardContentsClient interface {
+	Recv() (*GetShardContentsResponse, error)
+	grpc.ClientStream
+}
+
+type kvStreamShardContentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *kvStreamShardContentsClient) Recv() (*GetShardContentsResponse, error) {
+	m := new(GetShardContentsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kvClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/Scan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Kv_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Kv_ServiceDesc.Streams[1], "/kv.Kv/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kvWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Kv_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type kvWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *kvWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kvClient) ReplicaWrite(ctx context.Context, in *ReplicaWriteRequest, opts ...grpc.CallOption) (*ReplicaWriteResponse, error) {
+	out := new(ReplicaWriteResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/ReplicaWrite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) StoreHint(ctx context.Context, in *StoreHintRequest, opts ...grpc.CallOption) (*StoreHintResponse, error) {
+	out := new(StoreHintResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/StoreHint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) GetMerkleHashes(ctx context.Context, in *MerkleHashesRequest, opts ...grpc.CallOption) (*MerkleHashesResponse, error) {
+	out := new(MerkleHashesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/GetMerkleHashes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) GetMerkleLeaves(ctx context.Context, in *MerkleLeavesRequest, opts ...grpc.CallOption) (*MerkleLeavesResponse, error) {
+	out := new(MerkleLeavesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/GetMerkleLeaves", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) BackupWrite(ctx context.Context, in *BackupWriteRequest, opts ...grpc.CallOption) (*BackupWriteResponse, error) {
+	out := new(BackupWriteResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/BackupWrite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) ChainWrite(ctx context.Context, in *ChainWriteRequest, opts ...grpc.CallOption) (*ChainWriteResponse, error) {
+	out := new(ChainWriteResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/ChainWrite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) RaftRequestVote(ctx context.Context, in *RaftRequestVoteRequest, opts ...grpc.CallOption) (*RaftRequestVoteResponse, error) {
+	out := new(RaftRequestVoteResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/RaftRequestVote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) RaftAppendEntries(ctx context.Context, in *RaftAppendEntriesRequest, opts ...grpc.CallOption) (*RaftAppendEntriesResponse, error) {
+	out := new(RaftAppendEntriesResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/RaftAppendEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, "/kv.Kv/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KvServer is the server API for Kv service.
 // All implementations must embed UnimplementedKvServer
 // for forward compatibility
@@ -75,7 +388,58 @@ type KvServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Set(context.Context, *SetRequest) (*SetResponse, error)
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error)
+	// Same as Get/Set/Delete, for keys and values which are not valid UTF-8. Both
+	// families of calls operate on the same data.
+	GetBytes(context.Context, *GetBytesRequest) (*GetBytesResponse, error)
+	SetBytes(context.Context, *SetBytesRequest) (*SetBytesResponse, error)
+	DeleteBytes(context.Context, *DeleteBytesRequest) (*DeleteBytesResponse, error)
+	// Inspect or change the expiry of a key without touching its value or version.
+	GetTTL(context.Context, *GetTTLRequest) (*GetTTLResponse, error)
+	Touch(context.Context, *TouchRequest) (*TouchResponse, error)
+	// Makes a key never expire (until its next Set or Touch).
+	Persist(context.Context, *PersistRequest) (*PersistResponse, error)
+	// Atomically add to (or subtract from) a value holding a decimal int64.
+	Increment(context.Context, *IncrementRequest) (*IncrementResponse, error)
+	Decrement(context.Context, *DecrementRequest) (*DecrementResponse, error)
+	// Batched versions of Get/Set/Delete. Keys may belong to different shards;
+	// keys the node does not host fail individually, not the whole batch.
+	MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error)
+	MultiSet(context.Context, *MultiSetRequest) (*MultiSetResponse, error)
+	MultiDelete(context.Context, *MultiDeleteRequest) (*MultiDeleteResponse, error)
 	GetShardContents(context.Context, *GetShardContentsRequest) (*GetShardContentsResponse, error)
+	// Same contents as GetShardContents, but sent as a series of bounded chunks.
+	StreamShardContents(*StreamShardContentsRequest, Kv_StreamShardContentsServer) error
+	// Lists the keys of one shard in order, one page at a time.
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	// Streams changes to a key (or to keys with a prefix) in one shard, until the
+	// client cancels, the shard is moved off this node, or the client falls too
+	// far behind. Headers are sent once the subscription is active.
+	Watch(*WatchRequest, Kv_WatchServer) error
+	// Stores a value which another replica accepted, keeping its version, expiry
+	// and timestamp, unless the node has a newer one. Used by read repair.
+	ReplicaWrite(context.Context, *ReplicaWriteRequest) (*ReplicaWriteResponse, error)
+	// Asks a node which applied a write to forward the key to another replica
+	// which missed it, once that one is reachable again (hinted handoff). Fails
+	// with ResourceExhausted if the node holds too many hints already.
+	StoreHint(context.Context, *StoreHintRequest) (*StoreHintResponse, error)
+	// Anti-entropy between replicas: hashes of nodes of a shard's Merkle tree,
+	// and the entries under some of its leaves.
+	GetMerkleHashes(context.Context, *MerkleHashesRequest) (*MerkleHashesResponse, error)
+	GetMerkleLeaves(context.Context, *MerkleLeavesRequest) (*MerkleLeavesResponse, error)
+	// Applies a write on a backup of a shard, on behalf of its primary (see
+	// BackupWriteRequest). Fails with FailedPrecondition if the sender is not
+	// the primary according to the node.
+	BackupWrite(context.Context, *BackupWriteRequest) (*BackupWriteResponse, error)
+	// Applies a write on a node of a shard's chain, and passes it on down the
+	// chain, returning once the tail applied it (see ChainWriteRequest). Fails
+	// with FailedPrecondition if the sender is not the node's predecessor.
+	ChainWrite(context.Context, *ChainWriteRequest) (*ChainWriteResponse, error)
+	// Raft mode: the RPCs between the members of a shard's Raft group.
+	RaftRequestVote(context.Context, *RaftRequestVoteRequest) (*RaftRequestVoteResponse, error)
+	RaftAppendEntries(context.Context, *RaftAppendEntriesRequest) (*RaftAppendEntriesResponse, error)
+	// Node-level counters such as evictions, for operators and tests.
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	mustEmbedUnimplementedKvServer()
 }
 
@@ -92,9 +456,81 @@ func (UnimplementedKvServer) Set(context.Context, *SetRequest) (*SetResponse, er
 func (UnimplementedKvServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedKvServer) CompareAndSet(context.Context, *CompareAndSetRequest) (*CompareAndSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSet not implemented")
+}
+func (UnimplementedKvServer) GetBytes(context.Context, *GetBytesRequest) (*GetBytesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBytes not implemented")
+}
+func (UnimplementedKvServer) SetBytes(context.Context, *SetBytesRequest) (*SetBytesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBytes not implemented")
+}
+func (UnimplementedKvServer) DeleteBytes(context.Context, *DeleteBytesRequest) (*DeleteBytesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBytes not implemented")
+}
+func (UnimplementedKvServer) GetTTL(context.Context, *GetTTLRequest) (*GetTTLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTTL not implemented")
+}
+func (UnimplementedKvServer) Touch(context.Context, *TouchRequest) (*TouchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Touch not implemented")
+}
+func (UnimplementedKvServer) Persist(context.Context, *PersistRequest) (*PersistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Persist not implemented")
+}
+func (UnimplementedKvServer) Increment(context.Context, *IncrementRequest) (*IncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedKvServer) Decrement(context.Context, *DecrementRequest) (*DecrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decrement not implemented")
+}
+func (UnimplementedKvServer) MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiGet not implemented")
+}
+func (UnimplementedKvServer) MultiSet(context.Context, *MultiSetRequest) (*MultiSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiSet not implemented")
+}
+func (UnimplementedKvServer) MultiDelete(context.Context, *MultiDeleteRequest) (*MultiDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultiDelete not implemented")
+}
 func (UnimplementedKvServer) GetShardContents(context.Context, *GetShardContentsRequest) (*GetShardContentsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetShardContents not implemented")
 }
+func (UnimplementedKvServer) StreamShardContents(*StreamShardContentsRequest, Kv_StreamShardContentsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamShardContents not implemented")
+}
+func (UnimplementedKvServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedKvServer) Watch(*WatchRequest, Kv_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedKvServer) ReplicaWrite(context.Context, *ReplicaWriteRequest) (*ReplicaWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicaWrite not implemented")
+}
+func (UnimplementedKvServer) StoreHint(context.Context, *StoreHintRequest) (*StoreHintResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreHint not implemented")
+}
+func (UnimplementedKvServer) GetMerkleHashes(context.Context, *MerkleHashesRequest) (*MerkleHashesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMerkleHashes not implemented")
+}
+func (UnimplementedKvServer) GetMerkleLeaves(context.Context, *MerkleLeavesRequest) (*MerkleLeavesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMerkleLeaves not implemented")
+}
+func (UnimplementedKvServer) BackupWrite(context.Context, *BackupWriteRequest) (*BackupWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BackupWrite not implemented")
+}
+func (UnimplementedKvServer) ChainWrite(context.Context, *ChainWriteRequest) (*ChainWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChainWrite not implemented")
+}
+func (UnimplementedKvServer) RaftRequestVote(context.Context, *RaftRequestVoteRequest) (*RaftRequestVoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RaftRequestVote not implemented")
+}
+func (UnimplementedKvServer) RaftAppendEntries(context.Context, *RaftAppendEntriesRequest) (*RaftAppendEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RaftAppendEntries not implemented")
+}
+func (UnimplementedKvServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
 func (UnimplementedKvServer) mustEmbedUnimplementedKvServer() {}
 
 // UnsafeKvServer may be embedded to opt out of forward compatibility for this service.
@@ -162,46 +598,753 @@ func _Kv_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Kv_GetShardContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetShardContentsRequest)
+func _Kv_CompareAndSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareAndSetRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(KvServer).GetShardContents(ctx, in)
+		return srv.(KvServer).CompareAndSet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/kv.Kv/GetShardContents",
+		FullMethod: "/kv.Kv/CompareAndSet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(KvServer).GetShardContents(ctx, req.(*GetShardContentsRequest))
+		return srv.(KvServer).CompareAndSet(ctx, req.(*CompareAndSetRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// Kv_ServiceDesc is the grpc.ServiceDesc for Kv service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Kv_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "kv.Kv",
-	HandlerType: (*KvServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Get",
-			Handler:    _Kv_Get_Handler,
-		},
-		{
-			MethodName: "Set",
-			Handler:    _Kv_Set_Handler,
-		},
-		{
-			MethodName: "Delete",
-			Handler:    _Kv_Delete_Handler,
-		},
-		{
-			MethodName: "GetShardContents",
-			Handler:    _Kv_GetShardContents_Handler,
+func _Kv_GetBytes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBytesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetBytes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetBytes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetBytes(ctx, req.(*GetBytesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_SetBytes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBytesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).SetBytes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/SetBytes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).SetBytes(ctx, req.(*SetBytesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_DeleteBytes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBytesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).DeleteBytes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/DeleteBytes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).DeleteBytes(ctx, req.(*DeleteBytesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_GetTTL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTTLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetTTL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetTTL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetTTL(ctx, req.(*GetTTLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_Touch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TouchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).Touch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/Touch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).Touch(ctx, req.(*TouchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_Persist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PersistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).Persist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/Persist",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).Persist(ctx, req.(*PersistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/Increment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_Decrement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).Decrement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/Decrement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).Decrement(ctx, req.(*DecrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_MultiGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).MultiGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/MultiGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).MultiGet(ctx, req.(*MultiGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_MultiSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).MultiSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/MultiSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).MultiSet(ctx, req.(*MultiSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_MultiDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).MultiDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/MultiDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).MultiDelete(ctx, req.(*MultiDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_GetShardContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetShardContentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetShardContents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetShardContents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetShardContents(ctx, req.(*GetShardContentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_StreamShardContents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamShardContentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvServer).StreamShardContents(m, &kvStreamShardContentsServer{stream})
+}
+
+type Kv_StreamShardContentsServer interface {
+	Send(*GetShardContentsResponse) error
+	grpc.ServerStream
+}
+
+type kvStreamShardContentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *kvStreamShardContentsServer) Send(m *GetShardContentsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Kv_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KvServer).Watch(m, &kvWatchServer{stream})
+}
+
+type Kv_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type kvWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *kvWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Kv_ReplicaWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).ReplicaWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/ReplicaWrite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).ReplicaWrite(ctx, req.(*ReplicaWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_StoreHint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreHintRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).StoreHint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/StoreHint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).StoreHint(ctx, req.(*StoreHintRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_GetMerkleHashes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleHashesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetMerkleHashes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetMerkleHashes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetMerkleHashes(ctx, req.(*MerkleHashesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_GetMerkleLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetMerkleLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetMerkleLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetMerkleLeaves(ctx, req.(*MerkleLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_BackupWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BackupWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).BackupWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/BackupWrite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).BackupWrite(ctx, req.(*BackupWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_ChainWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChainWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).ChainWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/ChainWrite",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).ChainWrite(ctx, req.(*ChainWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_RaftRequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaftRequestVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).RaftRequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/RaftRequestVote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).RaftRequestVote(ctx, req.(*RaftRequestVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_RaftAppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaftAppendEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).RaftAppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/RaftAppendEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).RaftAppendEntries(ctx, req.(*RaftAppendEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kv_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.Kv/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Kv_ServiceDesc is the grpc.ServiceDesc for Kv service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kv_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.Kv",
+	HandlerType: (*KvServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _Kv_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Kv_Set_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Kv_Delete_Handler,
+		},
+		{
+			MethodName: "CompareAndSet",
+			Handler:    _Kv_CompareAndSet_Handler,
+		},
+		{
+			MethodName: "GetBytes",
+			Handler:    _Kv_GetBytes_Handler,
+		},
+		{
+			MethodName: "SetBytes",
+			Handler:    _Kv_SetBytes_Handler,
+		},
+		{
+			MethodName: "DeleteBytes",
+			Handler:    _Kv_DeleteBytes_Handler,
+		},
+		{
+			MethodName: "GetTTL",
+			Handler:    _Kv_GetTTL_Handler,
+		},
+		{
+			MethodName: "Touch",
+			Handler:    _Kv_Touch_Handler,
+		},
+		{
+			MethodName: "Persist",
+			Handler:    _Kv_Persist_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _Kv_Increment_Handler,
+		},
+		{
+			MethodName: "Decrement",
+			Handler:    _Kv_Decrement_Handler,
+		},
+		{
+			MethodName: "MultiGet",
+			Handler:    _Kv_MultiGet_Handler,
+		},
+		{
+			MethodName: "MultiSet",
+			Handler:    _Kv_MultiSet_Handler,
+		},
+		{
+			MethodName: "MultiDelete",
+			Handler:    _Kv_MultiDelete_Handler,
+		},
+		{
+			MethodName: "GetShardContents",
+			Handler:    _Kv_GetShardContents_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _Kv_Scan_Handler,
+		},
+		{
+			MethodName: "ReplicaWrite",
+			Handler:    _Kv_ReplicaWrite_Handler,
+		},
+		{
+			MethodName: "StoreHint",
+			Handler:    _Kv_StoreHint_Handler,
+		},
+		{
+			MethodName: "GetMerkleHashes",
+			Handler:    _Kv_GetMerkleHashes_Handler,
+		},
+		{
+			MethodName: "GetMerkleLeaves",
+			Handler:    _Kv_GetMerkleLeaves_Handler,
+		},
+		{
+			MethodName: "BackupWrite",
+			Handler:    _Kv_BackupWrite_Handler,
+		},
+		{
+			MethodName: "ChainWrite",
+			Handler:    _Kv_ChainWrite_Handler,
+		},
+		{
+			MethodName: "RaftRequestVote",
+			Handler:    _Kv_RaftRequestVote_Handler,
+		},
+		{
+			MethodName: "RaftAppendEntries",
+			Handler:    _Kv_RaftAppendEntries_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Kv_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamShardContents",
+			Handler:       _Kv_StreamShardContents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Kv_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kv/proto/kv.proto",
+}
+
+// KvAdminClient is the client API for KvAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KvAdminClient interface {
+	// Reports each shard's state and the progress of its latest copy.
+	GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error)
+	// Stops the copy of a shard (FailedPrecondition if none is running), which
+	// is then served as FAILED.
+	CancelShardCopy(ctx context.Context, in *CancelShardCopyRequest, opts ...grpc.CallOption) (*CancelShardCopyResponse, error)
+	// Copies a hosted shard in again in the background, merged under its
+	// current contents. Fails with FailedPrecondition while a copy of the
+	// shard is running.
+	RetryShardCopy(ctx context.Context, in *RetryShardCopyRequest, opts ...grpc.CallOption) (*RetryShardCopyResponse, error)
+}
+
+type kvAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKvAdminClient(cc grpc.ClientConnInterface) KvAdminClient {
+	return &kvAdminClient{cc}
+}
+
+func (c *kvAdminClient) GetMigrationStatus(ctx context.Context, in *GetMigrationStatusRequest, opts ...grpc.CallOption) (*GetMigrationStatusResponse, error) {
+	out := new(GetMigrationStatusResponse)
+	err := c.cc.Invoke(ctx, "/kv.KvAdmin/GetMigrationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvAdminClient) CancelShardCopy(ctx context.Context, in *CancelShardCopyRequest, opts ...grpc.CallOption) (*CancelShardCopyResponse, error) {
+	out := new(CancelShardCopyResponse)
+	err := c.cc.Invoke(ctx, "/kv.KvAdmin/CancelShardCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kvAdminClient) RetryShardCopy(ctx context.Context, in *RetryShardCopyRequest, opts ...grpc.CallOption) (*RetryShardCopyResponse, error) {
+	out := new(RetryShardCopyResponse)
+	err := c.cc.Invoke(ctx, "/kv.KvAdmin/RetryShardCopy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KvAdminServer is the server API for KvAdmin service.
+// All implementations must embed UnimplementedKvAdminServer
+// for forward compatibility
+type KvAdminServer interface {
+	// Reports each shard's state and the progress of its latest copy.
+	GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error)
+	// Stops the copy of a shard (FailedPrecondition if none is running), which
+	// is then served as FAILED.
+	CancelShardCopy(context.Context, *CancelShardCopyRequest) (*CancelShardCopyResponse, error)
+	// Copies a hosted shard in again in the background, merged under its
+	// current contents. Fails with FailedPrecondition while a copy of the
+	// shard is running.
+	RetryShardCopy(context.Context, *RetryShardCopyRequest) (*RetryShardCopyResponse, error)
+	mustEmbedUnimplementedKvAdminServer()
+}
+
+// UnimplementedKvAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedKvAdminServer struct {
+}
+
+func (UnimplementedKvAdminServer) GetMigrationStatus(context.Context, *GetMigrationStatusRequest) (*GetMigrationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMigrationStatus not implemented")
+}
+func (UnimplementedKvAdminServer) CancelShardCopy(context.Context, *CancelShardCopyRequest) (*CancelShardCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelShardCopy not implemented")
+}
+func (UnimplementedKvAdminServer) RetryShardCopy(context.Context, *RetryShardCopyRequest) (*RetryShardCopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryShardCopy not implemented")
+}
+func (UnimplementedKvAdminServer) mustEmbedUnimplementedKvAdminServer() {}
+
+// UnsafeKvAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KvAdminServer will
+// result in compilation errors.
+type UnsafeKvAdminServer interface {
+	mustEmbedUnimplementedKvAdminServer()
+}
+
+func RegisterKvAdminServer(s grpc.ServiceRegistrar, srv KvAdminServer) {
+	s.RegisterService(&KvAdmin_ServiceDesc, srv)
+}
+
+func _KvAdmin_GetMigrationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMigrationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvAdminServer).GetMigrationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KvAdmin/GetMigrationStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvAdminServer).GetMigrationStatus(ctx, req.(*GetMigrationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvAdmin_CancelShardCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelShardCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvAdminServer).CancelShardCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KvAdmin/CancelShardCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvAdminServer).CancelShardCopy(ctx, req.(*CancelShardCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KvAdmin_RetryShardCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryShardCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KvAdminServer).RetryShardCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kv.KvAdmin/RetryShardCopy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KvAdminServer).RetryShardCopy(ctx, req.(*RetryShardCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// KvAdmin_ServiceDesc is the grpc.ServiceDesc for KvAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KvAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kv.KvAdmin",
+	HandlerType: (*KvAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMigrationStatus",
+			Handler:    _KvAdmin_GetMigrationStatus_Handler,
+		},
+		{
+			MethodName: "CancelShardCopy",
+			Handler:    _KvAdmin_CancelShardCopy_Handler,
+		},
+		{
+			MethodName: "RetryShardCopy",
+			Handler:    _KvAdmin_RetryShardCopy_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
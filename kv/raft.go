@@ -0,0 +1,910 @@
+package kv
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Raft (https://raft.github.io/raft.pdf) for the replicas of one shard, used in
+ * Raft mode (see raftshards.go for how groups follow the ShardMap and serve
+ * clients). The log holds WriteCommands, applied in order once committed, and
+ * changes of the group's members, one node added or removed at a time. As in
+ * the Raft dissertation, a node uses the latest config in its log, committed or
+ * not.
+ *
+ * Reads are linearizable through readIndex: the leader serves them at its commit
+ * index once it knows it still is the leader, either from its lease (a majority
+ * acked a heartbeat less than raftLeaseDuration ago, and followers do not vote
+ * for anyone else for raftElectionTimeout after hearing from a leader) or by
+ * sending a heartbeat round first.
+ *
+ * The term, the vote and the log are synced to disk before a member acts on
+ * them (see raftlog.go), so a restarted member keeps its promises and its
+ * entries. The commit index is not: a restarted member applies its log again
+ * from the start as it learns what was committed.
+ *
+ * NOTE: the log is never compacted: a shard's log holds every write made to it
+ * since the group was formed, which new members get all of.
+ */
+
+const (
+	raftTick              = 10 * time.Millisecond
+	raftHeartbeatInterval = 50 * time.Millisecond
+	// followers wait between this and twice this without hearing from a leader
+	// before starting an election
+	raftElectionTimeout = 300 * time.Millisecond
+	// kept below raftElectionTimeout, as a margin for clocks running at
+	// slightly different rates
+	raftLeaseDuration = raftElectionTimeout * 3 / 4
+	raftRPCTimeout    = 100 * time.Millisecond
+	// most entries sent in one AppendEntries
+	raftMaxAppendEntries = 256
+)
+
+type raftRole int
+
+const (
+	raftFollower raftRole = iota
+	raftCandidate
+	raftLeader
+)
+
+type raftOutcome struct {
+	result writeResult
+	err    error
+}
+
+// A proposal waiting for the entry at its index to be applied
+type raftWaiter struct {
+	term    uint64
+	outcome chan raftOutcome
+}
+
+type raftGroup struct {
+	shard      int
+	self       string
+	clientPool ClientPool
+	stats      *Stats
+	// applies a committed command to the shard
+	apply func(*proto.WriteCommand) (writeResult, error)
+	// nodes the ShardMap assigns the shard to, which the leader moves the
+	// group's config towards
+	target func() []string
+
+	mutex sync.Mutex
+	// closed (and replaced) whenever the state below changes, see waitFor
+	changed chan struct{}
+	stopped bool
+	done    chan struct{}
+	// closed once applyLoop returned
+	applyDone chan struct{}
+
+	storage *raftStorage
+	// set once writing to storage failed, after which we neither vote, ack
+	// entries nor lead: we could not keep promises we did not persist
+	storageErr error
+
+	term     uint64
+	votedFor string
+	// log[i] is the entry at index i; log[0] is a placeholder holding the
+	// initial config, if any
+	log         []*proto.RaftEntry
+	configIndex uint64
+	commitIndex uint64
+	lastApplied uint64
+
+	role   raftRole
+	leader string
+	// when we last heard from the leader of the current term
+	heardFromLeader  time.Time
+	electionDeadline time.Time
+
+	// leader state
+	nextIndex     map[string]uint64
+	matchIndex    map[string]uint64
+	inflight      map[string]bool
+	lastBroadcast time.Time
+	// for each peer, when the last AppendEntries it acked this term was sent
+	ackedSentAt map[string]time.Time
+	leaderSince time.Time
+	// index of the no-op the leader started its term with: reads wait for it to
+	// be committed, so the commit index covers every earlier term
+	termStart uint64
+	waiters   map[uint64]*raftWaiter
+}
+
+/*
+ * A group for `shard` with `config` as its initial members, keeping its state in
+ * `dataDir`. Nodes which join a group later start with no config instead, and do
+ * not campaign until they learned one including them from the leader. A member
+ * which was part of the group before a restart picks up where it left off
+ * instead, whatever `config` is.
+ */
+func newRaftGroup(shard int, self string, config []string, dataDir string, clientPool ClientPool, stats *Stats, apply func(*proto.WriteCommand) (writeResult, error), target func() []string) (*raftGroup, error) {
+	storage, state, err := openRaftStorage(dataDir, shard)
+	if err != nil {
+		return nil, err
+	}
+	if state.log == nil {
+		placeholder := &proto.RaftEntry{}
+		if config != nil {
+			placeholder.Config = &proto.RaftConfig{Nodes: slices.Clone(config)}
+		}
+		state.log = []*proto.RaftEntry{placeholder}
+		if err := storage.saveEntries(0, state.log); err != nil {
+			storage.close()
+			return nil, err
+		}
+	}
+	g := &raftGroup{
+		shard:      shard,
+		self:       self,
+		clientPool: clientPool,
+		stats:      stats,
+		apply:      apply,
+		target:     target,
+		changed:    make(chan struct{}),
+		done:       make(chan struct{}),
+		applyDone:  make(chan struct{}),
+		storage:    storage,
+		term:       state.term,
+		votedFor:   state.votedFor,
+		log:        state.log,
+		waiters:    make(map[uint64]*raftWaiter),
+	}
+	for index, entry := range g.log {
+		if entry.Config != nil {
+			g.configIndex = uint64(index)
+		}
+	}
+	g.resetElectionDeadline()
+	go g.run()
+	go g.applyLoop()
+	return g, nil
+}
+
+// Stops the group, returning once it no longer applies commands
+func (g *raftGroup) stop() {
+	g.mutex.Lock()
+	if !g.stopped {
+		g.stopped = true
+		close(g.done)
+		for index, waiter := range g.waiters {
+			waiter.outcome <- raftOutcome{err: status.Error(codes.Aborted, "Raft group stopped, the write may or may not be committed")}
+			delete(g.waiters, index)
+		}
+		g.notify()
+		if err := g.storage.close(); err != nil {
+			logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard}).Errorf("failed to close Raft log: %q", err)
+		}
+	}
+	g.mutex.Unlock()
+	<-g.applyDone
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+/*
+ * Waits until `condition` (called with the mutex held) is true. Fails if ctx is
+ * done or the group stopped first.
+ */
+func (g *raftGroup) waitFor(ctx context.Context, condition func() bool) error {
+	for {
+		g.mutex.Lock()
+		if g.stopped {
+			g.mutex.Unlock()
+			return status.Error(codes.Unavailable, "Raft group stopped")
+		}
+		if condition() {
+			g.mutex.Unlock()
+			return nil
+		}
+		changed := g.changed
+		g.mutex.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) lastIndex() uint64 {
+	return uint64(len(g.log) - 1)
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) config() []string {
+	if config := g.log[g.configIndex].Config; config != nil {
+		return config.Nodes
+	}
+	return nil
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) isMember() bool {
+	return slices.Contains(g.config(), g.self)
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) resetElectionDeadline() {
+	timeout := raftElectionTimeout + time.Duration(rand.Int63n(int64(raftElectionTimeout)))
+	g.electionDeadline = time.Now().Add(timeout)
+}
+
+// Leader we know of for the current term, "" if none
+func (g *raftGroup) knownLeader() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.leader
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) notLeaderError() error {
+	return notPrimaryError(g.shard, g.leader)
+}
+
+// Syncs the term and vote to storage. NOTE: must hold the mutex
+func (g *raftGroup) persistState() {
+	if g.stopped || g.storageErr != nil {
+		return
+	}
+	if err := g.storage.saveState(g.term, g.votedFor); err != nil {
+		g.storageFailed(err)
+	}
+}
+
+// Syncs the log from index `first` on to storage. NOTE: must hold the mutex
+func (g *raftGroup) persistEntries(first uint64) {
+	if g.stopped || g.storageErr != nil {
+		return
+	}
+	if err := g.storage.saveEntries(first, g.log[first:]); err != nil {
+		g.storageFailed(err)
+	}
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) storageFailed(err error) {
+	logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard}).Errorf("failed to write Raft log, no longer voting nor leading: %q", err)
+	g.storageErr = err
+	g.stats.Add("raft_storage_errors", 1)
+	if g.role != raftFollower {
+		g.becomeFollower(g.term)
+	}
+}
+
+// NOTE: must hold the mutex. Adopts a newer term (if `term` is one) as a follower.
+func (g *raftGroup) becomeFollower(term uint64) {
+	if term > g.term {
+		g.term = term
+		g.votedFor = ""
+		g.leader = ""
+		g.persistState()
+	}
+	if g.leader == g.self {
+		g.leader = ""
+	}
+	if g.role != raftFollower {
+		g.role = raftFollower
+		g.resetElectionDeadline()
+	}
+	g.notify()
+}
+
+// Appends entries to the log, keeping configIndex up to date. NOTE: must hold the mutex
+func (g *raftGroup) appendEntries(entries ...*proto.RaftEntry) {
+	first := g.lastIndex() + 1
+	for _, entry := range entries {
+		g.log = append(g.log, entry)
+		if entry.Config != nil {
+			g.configIndex = g.lastIndex()
+		}
+	}
+	g.persistEntries(first)
+}
+
+/*
+ * Drops the entries from `index` on, failing the proposals waiting for them.
+ * Only ever drops uncommitted entries. NOTE: must hold the mutex
+ */
+func (g *raftGroup) truncateLog(index uint64) {
+	for i := index; i <= g.lastIndex(); i++ {
+		if waiter, exists := g.waiters[i]; exists {
+			waiter.outcome <- raftOutcome{err: status.Error(codes.Unavailable, "lost Raft leadership before the write was committed")}
+			delete(g.waiters, i)
+		}
+	}
+	g.log = g.log[:index]
+	g.persistEntries(index)
+	for g.configIndex >= index {
+		g.configIndex--
+		for g.configIndex > 0 && g.log[g.configIndex].Config == nil {
+			g.configIndex--
+		}
+	}
+}
+
+func (g *raftGroup) run() {
+	ticker := time.NewTicker(raftTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+		}
+		g.tick()
+	}
+}
+
+func (g *raftGroup) tick() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.stopped {
+		return
+	}
+	now := time.Now()
+	if g.role != raftLeader {
+		if g.isMember() && g.storageErr == nil && now.After(g.electionDeadline) {
+			g.startElection()
+		}
+		return
+	}
+
+	// step down if we lost touch with a majority, so clients look elsewhere
+	contact := g.quorumContact()
+	if contact.Before(g.leaderSince) {
+		contact = g.leaderSince
+	}
+	if now.Sub(contact) > raftElectionTimeout {
+		logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard, "term": g.term}).Debug("Raft leader lost contact with a majority")
+		g.becomeFollower(g.term)
+		return
+	}
+	g.changeMembership()
+	if now.Sub(g.lastBroadcast) >= raftHeartbeatInterval {
+		g.broadcast(true)
+	}
+}
+
+/*
+ * The time a majority of the config last acked us by (counting ourselves as
+ * now), i.e. since when we are known to have been the leader.
+ *
+ * NOTE: must hold the mutex
+ */
+func (g *raftGroup) quorumContact() time.Time {
+	config := g.config()
+	times := make([]time.Time, 0, len(config))
+	for _, node := range config {
+		if node == g.self {
+			times = append(times, time.Now())
+		} else {
+			times = append(times, g.ackedSentAt[node])
+		}
+	}
+	if len(times) == 0 {
+		return time.Time{}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return b.Compare(a) })
+	return times[len(times)/2]
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) startElection() {
+	g.term++
+	g.role = raftCandidate
+	g.votedFor = g.self
+	g.leader = ""
+	g.resetElectionDeadline()
+	g.stats.Add("raft_elections", 1)
+	g.notify()
+	g.persistState()
+	if g.storageErr != nil {
+		return
+	}
+
+	term := g.term
+	config := g.config()
+	request := &proto.RaftRequestVoteRequest{
+		Shard:        int32(g.shard),
+		Term:         term,
+		Candidate:    g.self,
+		LastLogIndex: g.lastIndex(),
+		LastLogTerm:  g.log[g.lastIndex()].Term,
+	}
+	votes := 1
+	if votes >= len(config)/2+1 {
+		g.becomeLeader()
+		return
+	}
+	for _, node := range config {
+		if node == g.self {
+			continue
+		}
+		go func(node string) {
+			response, err := g.requestVote(node, request)
+			if err != nil {
+				return
+			}
+			g.mutex.Lock()
+			defer g.mutex.Unlock()
+			if response.Term > g.term {
+				g.becomeFollower(response.Term)
+				return
+			}
+			if g.role != raftCandidate || g.term != term || !response.VoteGranted {
+				return
+			}
+			votes++
+			if votes == len(config)/2+1 {
+				g.becomeLeader()
+			}
+		}(node)
+	}
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) becomeLeader() {
+	logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard, "term": g.term}).Debug("became Raft leader")
+	g.stats.Add("raft_leaderships", 1)
+	g.role = raftLeader
+	g.leader = g.self
+	g.nextIndex = make(map[string]uint64)
+	g.matchIndex = make(map[string]uint64)
+	g.inflight = make(map[string]bool)
+	g.ackedSentAt = make(map[string]time.Time)
+	g.leaderSince = time.Now()
+	g.appendEntries(&proto.RaftEntry{Term: g.term})
+	if g.storageErr != nil {
+		return
+	}
+	g.termStart = g.lastIndex()
+	g.advanceCommitIndex()
+	g.broadcast(true)
+	g.notify()
+}
+
+/*
+ * Sends AppendEntries to every other member which does not have a call in
+ * flight: heartbeats if `heartbeat` is set, otherwise only to those behind.
+ *
+ * NOTE: must hold the mutex
+ */
+func (g *raftGroup) broadcast(heartbeat bool) {
+	if g.role != raftLeader {
+		return
+	}
+	if heartbeat {
+		g.lastBroadcast = time.Now()
+	}
+	for _, node := range g.config() {
+		if node == g.self || g.inflight[node] {
+			continue
+		}
+		if _, known := g.nextIndex[node]; !known {
+			g.nextIndex[node] = g.lastIndex() + 1
+		}
+		if heartbeat || g.nextIndex[node] <= g.lastIndex() {
+			g.sendAppendEntries(node)
+		}
+	}
+}
+
+// NOTE: must hold the mutex
+func (g *raftGroup) sendAppendEntries(node string) {
+	next := g.nextIndex[node]
+	last := min(g.lastIndex(), next+raftMaxAppendEntries-1)
+	request := &proto.RaftAppendEntriesRequest{
+		Shard:        int32(g.shard),
+		Term:         g.term,
+		Leader:       g.self,
+		PrevLogIndex: next - 1,
+		PrevLogTerm:  g.log[next-1].Term,
+		Entries:      slices.Clone(g.log[next : last+1]),
+		LeaderCommit: g.commitIndex,
+	}
+	g.inflight[node] = true
+	term := g.term
+	go func() {
+		sentAt := time.Now()
+		response, err := g.callAppendEntries(node, request)
+
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		if g.role != raftLeader || g.term != term {
+			if err == nil && response.Term > g.term {
+				g.becomeFollower(response.Term)
+			}
+			return
+		}
+		g.inflight[node] = false
+		if err != nil {
+			return
+		}
+		if response.Term > g.term {
+			g.becomeFollower(response.Term)
+			return
+		}
+		if sentAt.After(g.ackedSentAt[node]) {
+			g.ackedSentAt[node] = sentAt
+		}
+		if response.Success {
+			match := request.PrevLogIndex + uint64(len(request.Entries))
+			if match > g.matchIndex[node] {
+				g.matchIndex[node] = match
+			}
+			g.nextIndex[node] = g.matchIndex[node] + 1
+			g.advanceCommitIndex()
+		} else {
+			g.nextIndex[node] = max(1, min(response.ConflictIndex, g.nextIndex[node]-1))
+		}
+		g.notify()
+		if g.nextIndex[node] <= g.lastIndex() && slices.Contains(g.config(), node) {
+			g.sendAppendEntries(node)
+		}
+	}()
+}
+
+/*
+ * Commits the latest entry of our term which a majority of the config has.
+ *
+ * NOTE: must hold the mutex
+ */
+func (g *raftGroup) advanceCommitIndex() {
+	if g.storageErr != nil {
+		// our own log may not hold what we count it for
+		return
+	}
+	config := g.config()
+	matches := make([]uint64, 0, len(config))
+	for _, node := range config {
+		if node == g.self {
+			matches = append(matches, g.lastIndex())
+		} else {
+			matches = append(matches, g.matchIndex[node])
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	slices.Sort(matches)
+	slices.Reverse(matches)
+	committed := matches[len(matches)/2]
+	if committed <= g.commitIndex || g.log[committed].Term != g.term {
+		return
+	}
+	g.commitIndex = committed
+	g.notify()
+
+	// a committed config which removed us ends our leadership
+	if g.configIndex <= g.commitIndex && !g.isMember() {
+		logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard}).Debug("Raft leader removed from the group")
+		g.becomeFollower(g.term)
+	}
+}
+
+/*
+ * Moves the config one node closer to the nodes the ShardMap assigns the shard
+ * to: first adding missing nodes, then removing extra ones (ourselves last).
+ * Waits for the previous change to commit before making the next one.
+ *
+ * NOTE: must hold the mutex
+ */
+func (g *raftGroup) changeMembership() {
+	if g.configIndex > g.commitIndex || g.commitIndex < g.termStart {
+		return
+	}
+	target := g.target()
+	if len(target) == 0 {
+		return
+	}
+	config := g.config()
+	var next []string
+	for _, node := range target {
+		if !slices.Contains(config, node) {
+			next = append(slices.Clone(config), node)
+			break
+		}
+	}
+	if next == nil {
+		extra := slices.DeleteFunc(slices.Clone(config), func(node string) bool {
+			return slices.Contains(target, node)
+		})
+		if len(extra) == 0 {
+			return
+		}
+		remove := extra[0]
+		for _, node := range extra {
+			if node != g.self {
+				remove = node
+				break
+			}
+		}
+		next = slices.DeleteFunc(slices.Clone(config), func(node string) bool { return node == remove })
+	}
+	logrus.WithFields(logrus.Fields{"node": g.self, "shard": g.shard, "config": next}).Debug("changing Raft group members")
+	g.stats.Add("raft_config_changes", 1)
+	g.appendEntries(&proto.RaftEntry{Term: g.term, Config: &proto.RaftConfig{Nodes: next}})
+	g.advanceCommitIndex()
+	g.broadcast(false)
+}
+
+// Applies committed entries in order, handing their outcome to waiting proposals
+func (g *raftGroup) applyLoop() {
+	defer close(g.applyDone)
+	for {
+		g.mutex.Lock()
+		for !g.stopped && g.lastApplied >= g.commitIndex {
+			changed := g.changed
+			g.mutex.Unlock()
+			<-changed
+			g.mutex.Lock()
+		}
+		if g.stopped {
+			g.mutex.Unlock()
+			return
+		}
+		first := g.lastApplied + 1
+		entries := slices.Clone(g.log[first : g.commitIndex+1])
+		g.mutex.Unlock()
+
+		for i, entry := range entries {
+			g.mutex.Lock()
+			stopped := g.stopped
+			g.mutex.Unlock()
+			if stopped {
+				return
+			}
+			var outcome raftOutcome
+			if entry.Command != nil {
+				outcome.result, outcome.err = g.apply(entry.Command)
+			}
+			index := first + uint64(i)
+			g.mutex.Lock()
+			g.lastApplied = index
+			if waiter, exists := g.waiters[index]; exists {
+				if waiter.term != entry.Term {
+					outcome = raftOutcome{err: status.Error(codes.Unavailable, "lost Raft leadership before the write was committed")}
+				}
+				waiter.outcome <- outcome
+				delete(g.waiters, index)
+			}
+			g.notify()
+			g.mutex.Unlock()
+		}
+	}
+}
+
+/*
+ * Appends `command` to the log if we are the leader, and waits for it to be
+ * committed and applied. Fails with a NotPrimary error naming the leader (if
+ * known) otherwise.
+ */
+func (g *raftGroup) propose(ctx context.Context, command *proto.WriteCommand) (writeResult, error) {
+	g.mutex.Lock()
+	if g.stopped {
+		g.mutex.Unlock()
+		return writeResult{}, status.Error(codes.Unavailable, "Raft group stopped")
+	}
+	if g.role != raftLeader {
+		err := g.notLeaderError()
+		g.mutex.Unlock()
+		return writeResult{}, err
+	}
+	g.appendEntries(&proto.RaftEntry{Term: g.term, Command: command})
+	if g.storageErr != nil {
+		g.mutex.Unlock()
+		return writeResult{}, status.Error(codes.Unavailable, "failed to write the Raft log")
+	}
+	index := g.lastIndex()
+	waiter := &raftWaiter{term: g.term, outcome: make(chan raftOutcome, 1)}
+	g.waiters[index] = waiter
+	g.advanceCommitIndex()
+	g.broadcast(false)
+	g.mutex.Unlock()
+
+	select {
+	case outcome := <-waiter.outcome:
+		return outcome.result, outcome.err
+	case <-ctx.Done():
+		g.mutex.Lock()
+		delete(g.waiters, index)
+		g.mutex.Unlock()
+		return writeResult{}, status.Errorf(codes.DeadlineExceeded, "write not committed yet (it may still be): %v", ctx.Err())
+	}
+}
+
+/*
+ * Waits until reads of the shard are linearizable: we are the leader and applied
+ * everything committed when the read arrived.
+ */
+func (g *raftGroup) readIndex(ctx context.Context) error {
+	g.mutex.Lock()
+	if g.role != raftLeader {
+		err := g.notLeaderError()
+		g.mutex.Unlock()
+		return err
+	}
+	term := g.term
+	g.mutex.Unlock()
+
+	isLeader := func() bool { return g.role == raftLeader && g.term == term }
+	if err := g.waitFor(ctx, func() bool { return !isLeader() || g.commitIndex >= g.termStart }); err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	if !isLeader() {
+		err := g.notLeaderError()
+		g.mutex.Unlock()
+		return err
+	}
+	index := g.commitIndex
+	if time.Since(g.quorumContact()) < raftLeaseDuration {
+		g.stats.Add("raft_lease_reads", 1)
+	} else {
+		// confirm we still are the leader with a round of heartbeats
+		g.stats.Add("raft_read_index_rounds", 1)
+		start := time.Now()
+		g.broadcast(true)
+		g.mutex.Unlock()
+		if err := g.waitFor(ctx, func() bool { return !isLeader() || !g.quorumContact().Before(start) }); err != nil {
+			return err
+		}
+		g.mutex.Lock()
+		if !isLeader() {
+			err := g.notLeaderError()
+			g.mutex.Unlock()
+			return err
+		}
+	}
+	g.mutex.Unlock()
+	return g.waitFor(ctx, func() bool { return g.lastApplied >= index })
+}
+
+/*
+ * Whether the group is done with us, so that once the ShardMap no longer assigns
+ * us the shard, it can be dropped: we are not a member anymore, or no leader
+ * contacted us for `timeout` (leaders stop sending to members they removed, who
+ * never hear about it).
+ */
+func (g *raftGroup) retired(timeout time.Duration) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.role == raftLeader {
+		// removes itself from the config first
+		return false
+	}
+	return !g.isMember() || time.Since(g.heardFromLeader) > timeout
+}
+
+// Fails RPCs to a member which stopped, or can no longer write its log. NOTE: must hold the mutex
+func (g *raftGroup) checkAvailable() error {
+	if g.stopped {
+		return status.Error(codes.Unavailable, "Raft group stopped")
+	}
+	if g.storageErr != nil {
+		return status.Errorf(codes.Unavailable, "failed to write the Raft log: %v", g.storageErr)
+	}
+	return nil
+}
+
+func (g *raftGroup) handleRequestVote(request *proto.RaftRequestVoteRequest) (*proto.RaftRequestVoteResponse, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if err := g.checkAvailable(); err != nil {
+		return nil, err
+	}
+	if request.Term < g.term {
+		return &proto.RaftRequestVoteResponse{Term: g.term}, nil
+	}
+	// while a leader is in touch with us, ignore candidates (which keeps leader
+	// leases safe, and removed members from disrupting the group)
+	if request.Term > g.term && (g.role == raftLeader || (g.leader != "" && time.Since(g.heardFromLeader) < raftElectionTimeout)) {
+		return &proto.RaftRequestVoteResponse{Term: g.term}, nil
+	}
+	if request.Term > g.term {
+		g.becomeFollower(request.Term)
+	}
+	lastTerm := g.log[g.lastIndex()].Term
+	upToDate := request.LastLogTerm > lastTerm || (request.LastLogTerm == lastTerm && request.LastLogIndex >= g.lastIndex())
+	if (g.votedFor == "" || g.votedFor == request.Candidate) && upToDate {
+		g.votedFor = request.Candidate
+		g.persistState()
+		if err := g.checkAvailable(); err != nil {
+			return nil, err
+		}
+		g.resetElectionDeadline()
+		return &proto.RaftRequestVoteResponse{Term: g.term, VoteGranted: true}, nil
+	}
+	return &proto.RaftRequestVoteResponse{Term: g.term}, nil
+}
+
+func (g *raftGroup) handleAppendEntries(request *proto.RaftAppendEntriesRequest) (*proto.RaftAppendEntriesResponse, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if err := g.checkAvailable(); err != nil {
+		return nil, err
+	}
+	if request.Term < g.term {
+		return &proto.RaftAppendEntriesResponse{Term: g.term}, nil
+	}
+	if request.Term > g.term || g.role != raftFollower {
+		g.becomeFollower(request.Term)
+	}
+	if g.leader != request.Leader {
+		g.leader = request.Leader
+		g.notify()
+	}
+	g.heardFromLeader = time.Now()
+	g.resetElectionDeadline()
+
+	if request.PrevLogIndex > g.lastIndex() {
+		return &proto.RaftAppendEntriesResponse{Term: g.term, ConflictIndex: g.lastIndex() + 1}, nil
+	}
+	if conflictTerm := g.log[request.PrevLogIndex].Term; conflictTerm != request.PrevLogTerm {
+		// skip back over the whole conflicting term at once
+		conflict := request.PrevLogIndex
+		for conflict > g.commitIndex+1 && g.log[conflict-1].Term == conflictTerm {
+			conflict--
+		}
+		return &proto.RaftAppendEntriesResponse{Term: g.term, ConflictIndex: conflict}, nil
+	}
+
+	for i, entry := range request.Entries {
+		index := request.PrevLogIndex + 1 + uint64(i)
+		if index <= g.lastIndex() {
+			if g.log[index].Term == entry.Term {
+				continue
+			}
+			g.truncateLog(index)
+		}
+		g.appendEntries(request.Entries[i:]...)
+		break
+	}
+	if err := g.checkAvailable(); err != nil {
+		return nil, err
+	}
+	// entries past the ones sent may not be the leader's
+	if commit := min(request.LeaderCommit, request.PrevLogIndex+uint64(len(request.Entries))); commit > g.commitIndex {
+		g.commitIndex = commit
+		g.notify()
+	}
+	return &proto.RaftAppendEntriesResponse{Term: g.term, Success: true}, nil
+}
+
+func (g *raftGroup) requestVote(node string, request *proto.RaftRequestVoteRequest) (*proto.RaftRequestVoteResponse, error) {
+	client, err := g.clientPool.GetClient(node)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), raftRPCTimeout)
+	defer cancel()
+	return client.RaftRequestVote(ctx, request)
+}
+
+func (g *raftGroup) callAppendEntries(node string, request *proto.RaftAppendEntriesRequest) (*proto.RaftAppendEntriesResponse, error) {
+	client, err := g.clientPool.GetClient(node)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), raftRPCTimeout)
+	defer cancel()
+	return client.RaftAppendEntries(ctx, request)
+}
@@ -0,0 +1,198 @@
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+/*
+ * Durable state of a Raft group member (see raft.go): its term, its vote and its
+ * log. Raft is only safe if they survive restarts: a node which forgot its vote
+ * could vote twice in a term, and one which forgot its log could help elect a
+ * leader missing committed entries.
+ *
+ * Each member has an append-only file in the data directory, raft-<n>.log for
+ * shard n, made of the same CRC-checked frames as the WAL (see persistence.go).
+ * A record either sets the term and vote, or replaces the log from an index on
+ * with the entries it holds (none to truncate it). Records are synced before
+ * the member acts on them, e.g. replies to the RPC which made it write them.
+ * Like the log itself, the file is never compacted.
+ */
+
+type raftRecordOp uint8
+
+const (
+	raftRecordState raftRecordOp = iota + 1
+	raftRecordEntries
+)
+
+const (
+	raftLogPrefix = "raft-"
+	raftLogSuffix = ".log"
+)
+
+type raftStorage struct {
+	path string
+	file *os.File
+}
+
+// What a member recovers from its file
+type raftDurableState struct {
+	term     uint64
+	votedFor string
+	// nil if the file held no log
+	log []*proto.RaftEntry
+}
+
+/*
+ * Opens the file of the member for `shard` in `dir`, creating it if needed, and
+ * returns what it holds. A torn record at its tail is cut off.
+ */
+func openRaftStorage(dir string, shard int) (*raftStorage, *raftDurableState, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	storage := &raftStorage{path: filepath.Join(dir, fmt.Sprintf("%s%d%s", raftLogPrefix, shard, raftLogSuffix))}
+	state, valid, torn, err := storage.read()
+	if err != nil {
+		return nil, nil, err
+	}
+	if torn {
+		logrus.WithFields(logrus.Fields{"shard": shard, "bytes": valid}).Warn("truncating torn Raft log tail")
+		if err := os.Truncate(storage.path, valid); err != nil {
+			return nil, nil, err
+		}
+	}
+	storage.file, err = os.OpenFile(storage.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return storage, state, nil
+}
+
+/*
+ * Replays every well-formed record of the file. Returns the length of those
+ * records, and whether anything follows them (see persistence.readSegment).
+ */
+func (storage *raftStorage) read() (*raftDurableState, int64, bool, error) {
+	state := &raftDurableState{}
+	file, err := os.Open(storage.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	valid := int64(0)
+	for {
+		payload, err := readFrame(reader)
+		if err == io.EOF {
+			return state, valid, false, nil
+		}
+		if err == nil {
+			err = state.replay(payload)
+		}
+		if err != nil {
+			logrus.WithField("file", storage.path).Warnf("ignoring Raft log tail: %q", err)
+			return state, valid, true, nil
+		}
+		valid += int64(frameHeaderBytes + len(payload))
+	}
+}
+
+func (state *raftDurableState) replay(payload []byte) error {
+	reader := bytes.NewReader(payload)
+	op, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch raftRecordOp(op) {
+	case raftRecordState:
+		term, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		votedFor, err := readString(reader)
+		if err != nil {
+			return err
+		}
+		state.term, state.votedFor = term, votedFor
+	case raftRecordEntries:
+		first, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		count, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		if first > uint64(len(state.log)) {
+			return errCorruptFrame
+		}
+		log := state.log[:first]
+		for i := uint64(0); i < count; i++ {
+			encoded, err := readString(reader)
+			if err != nil {
+				return err
+			}
+			entry := &proto.RaftEntry{}
+			if err := gproto.Unmarshal([]byte(encoded), entry); err != nil {
+				return err
+			}
+			log = append(log, entry)
+		}
+		state.log = log
+	default:
+		return errCorruptFrame
+	}
+	return nil
+}
+
+func (storage *raftStorage) append(payload []byte) error {
+	if _, err := storage.file.Write(encodeFrame(payload)); err != nil {
+		return err
+	}
+	return storage.file.Sync()
+}
+
+func (storage *raftStorage) saveState(term uint64, votedFor string) error {
+	buf := []byte{byte(raftRecordState)}
+	buf = binary.AppendUvarint(buf, term)
+	return storage.append(appendString(buf, votedFor))
+}
+
+// Replaces the log from index `first` on with `entries`
+func (storage *raftStorage) saveEntries(first uint64, entries []*proto.RaftEntry) error {
+	buf := []byte{byte(raftRecordEntries)}
+	buf = binary.AppendUvarint(buf, first)
+	buf = binary.AppendUvarint(buf, uint64(len(entries)))
+	for _, entry := range entries {
+		encoded, err := gproto.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf = appendString(buf, string(encoded))
+	}
+	return storage.append(buf)
+}
+
+func (storage *raftStorage) close() error {
+	return storage.file.Close()
+}
+
+// Deletes the file, once the member left its group. NOTE: must be closed first
+func (storage *raftStorage) remove() error {
+	return os.Remove(storage.path)
+}
@@ -0,0 +1,222 @@
+package kv
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+ * Raft mode (KvServerOptions.Raft): each shard is replicated by a Raft group
+ * (see raft.go) formed by the nodes hosting it, which makes every operation on
+ * it linearizable. Only the group's leader serves clients: writes are committed
+ * in its log before being applied anywhere, and reads wait for readIndex. Other
+ * members answer with a FailedPrecondition error carrying a NotPrimary detail,
+ * which names the leader if they know it. Clients must set KvOptions.Raft.
+ *
+ * The groups follow the ShardMap instead of copying shards around: a node which
+ * gets a shard starts an empty group member, which the leader adds to its config
+ * and sends the whole log to, and members the ShardMap drops are removed from
+ * the config, one node at a time. Nodes leave a group once it is done with them
+ * (see raftGroup.retired), and only then drop their copy of the shard.
+ *
+ * Groups for the shards a node hosts when it starts are formed with those nodes
+ * as members, so all nodes must start from the same ShardMap. A restarted node
+ * rejoins the groups it was a member of from the state they left in DataDir,
+ * which Raft mode requires, and rebuilds their shards by applying their logs
+ * again: the usual write-ahead log and snapshots are not used. Raft mode cannot
+ * be combined with options which change replicas behind Raft's back (eviction,
+ * anti-entropy) or replicate writes otherwise (primary-backup).
+ */
+
+const (
+	// how long a write waits to be committed, unless the client gives up first
+	raftProposeTimeout = 2 * time.Second
+	// how long a node the ShardMap dropped keeps its group member without
+	// hearing from the leader (see raftGroup.retired)
+	raftRetireTimeout = 10 * raftElectionTimeout
+)
+
+type raftShards struct {
+	// where the groups keep their state (see raftlog.go)
+	dataDir string
+
+	mutex  sync.Mutex
+	groups map[int]*raftGroup
+	// whether the groups for the initial ShardMap were formed
+	started bool
+}
+
+func (server *KvServerImpl) raftGroupFor(shard int) *raftGroup {
+	server.raft.mutex.Lock()
+	defer server.raft.mutex.Unlock()
+	return server.raft.groups[shard]
+}
+
+/*
+ * Starts group members for the shards the ShardMap newly assigns us. Dropped
+ * shards are left to raftLoop, which waits for their groups to be done with us.
+ */
+func (server *KvServerImpl) updateRaftGroups() {
+	server.raft.mutex.Lock()
+	defer server.raft.mutex.Unlock()
+	bootstrap := !server.raft.started
+	server.raft.started = true
+
+	for _, shard := range server.shardMap.ShardsForNode(server.nodeName) {
+		if _, exists := server.raft.groups[shard]; exists {
+			continue
+		}
+		var config []string
+		if bootstrap {
+			config = server.shardMap.NodesForShard(shard)
+		}
+		logrus.WithFields(logrus.Fields{"node": server.nodeName, "shard": shard, "config": config}).Debug("starting Raft group member")
+
+		server.shardLock.Lock()
+		server.locks[shard-1].Lock()
+		server.clearShard(shard)
+		server.locks[shard-1].Unlock()
+		if server.hostedShards == nil {
+			server.hostedShards = make(map[int]bool)
+		}
+		server.hostedShards[shard] = true
+		server.shardLock.Unlock()
+
+		apply := func(command *proto.WriteCommand) (writeResult, error) {
+			return server.applyCommand(shard, command)
+		}
+		target := func() []string {
+			return server.shardMap.NodesForShard(shard)
+		}
+		group, err := newRaftGroup(shard, server.nodeName, config, server.raft.dataDir, server.clientPool, &server.stats, apply, target)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"node": server.nodeName, "shard": shard}).Errorf("failed to start Raft group member: %q", err)
+			continue
+		}
+		server.raft.groups[shard] = group
+	}
+}
+
+// Drops the groups of shards we no longer host once they are done with us
+func (server *KvServerImpl) retireRaftGroups() {
+	assigned := server.shardMap.ShardsForNode(server.nodeName)
+	server.raft.mutex.Lock()
+	defer server.raft.mutex.Unlock()
+	for shard, group := range server.raft.groups {
+		if slices.Contains(assigned, shard) || !group.retired(raftRetireTimeout) {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{"node": server.nodeName, "shard": shard}).Debug("leaving Raft group")
+		group.stop()
+		delete(server.raft.groups, shard)
+		if err := group.storage.remove(); err != nil {
+			logrus.WithFields(logrus.Fields{"node": server.nodeName, "shard": shard}).Errorf("failed to remove Raft log: %q", err)
+		}
+
+		server.shardLock.Lock()
+		delete(server.hostedShards, shard)
+		server.shardLock.Unlock()
+		server.locks[shard-1].Lock()
+		server.clearShard(shard)
+		server.watches.closeShard(shard, status.Error(codes.NotFound, "Shard moved off this server"))
+		server.locks[shard-1].Unlock()
+	}
+}
+
+func (server *KvServerImpl) raftLoop() {
+	ticker := time.NewTicker(raftHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-server.shutdown:
+			return
+		case <-ticker.C:
+			server.retireRaftGroups()
+		}
+	}
+}
+
+// Stops every group, once no more commands must be applied (on shutdown)
+func (server *KvServerImpl) stopRaftGroups() {
+	server.raft.mutex.Lock()
+	defer server.raft.mutex.Unlock()
+	for _, group := range server.raft.groups {
+		group.stop()
+	}
+}
+
+// Commits `command` in the log of the shard's group, then returns its outcome
+func (server *KvServerImpl) raftWrite(ctx context.Context, shard int, command *proto.WriteCommand) (writeResult, error) {
+	group := server.raftGroupFor(shard)
+	if group == nil {
+		return writeResult{}, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	ctx, cancel := context.WithTimeout(ctx, raftProposeTimeout)
+	defer cancel()
+	return group.propose(ctx, command)
+}
+
+// Waits until the shard can be read linearizably. No-op unless in Raft mode.
+func (server *KvServerImpl) raftRead(ctx context.Context, shard int) error {
+	if server.raft == nil {
+		return nil
+	}
+	group := server.raftGroupFor(shard)
+	if group == nil {
+		return status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	ctx, cancel := context.WithTimeout(ctx, raftProposeTimeout)
+	defer cancel()
+	return group.readIndex(ctx)
+}
+
+// Fails calls which would change replicas behind Raft's back
+func (server *KvServerImpl) rejectInRaftMode() error {
+	if server.raft != nil {
+		return status.Error(codes.FailedPrecondition, "shards are replicated by Raft on this server")
+	}
+	return nil
+}
+
+func (server *KvServerImpl) RaftRequestVote(
+	ctx context.Context,
+	request *proto.RaftRequestVoteRequest,
+) (*proto.RaftRequestVoteResponse, error) {
+	if err := server.checkRaftMode(); err != nil {
+		return nil, err
+	}
+	group := server.raftGroupFor(int(request.Shard))
+	if group == nil {
+		return nil, status.Error(codes.NotFound, "no Raft group for the shard on this server")
+	}
+	return group.handleRequestVote(request)
+}
+
+func (server *KvServerImpl) RaftAppendEntries(
+	ctx context.Context,
+	request *proto.RaftAppendEntriesRequest,
+) (*proto.RaftAppendEntriesResponse, error) {
+	if err := server.checkRaftMode(); err != nil {
+		return nil, err
+	}
+	group := server.raftGroupFor(int(request.Shard))
+	if group == nil {
+		return nil, status.Error(codes.NotFound, "no Raft group for the shard on this server")
+	}
+	return group.handleAppendEntries(request)
+}
+
+// Fails Raft RPCs to servers which are not in Raft mode
+func (server *KvServerImpl) checkRaftMode() error {
+	if server.raft == nil {
+		return status.Error(codes.FailedPrecondition, "Raft mode is off on this server")
+	}
+	return nil
+}
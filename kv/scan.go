@@ -0,0 +1,205 @@
+package kv
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cs426.yale.edu/lab4/kv/proto"
+)
+
+type ScanEntry struct {
+	Key   string
+	Value string
+}
+
+/*
+ * Cursors are opaque to callers: they encode the shard to continue from and the
+ * last key returned from it ("" to start at the beginning of the shard). An empty
+ * cursor means "start from the first shard".
+ */
+func encodeScanCursor(shard int, startAfter string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", shard, startAfter)))
+}
+
+func decodeScanCursor(cursor string) (int, string, error) {
+	if cursor == "" {
+		return 1, "", nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid scan cursor: %w", err)
+	}
+	shardStr, startAfter, found := strings.Cut(string(decoded), ":")
+	shard, err := strconv.Atoi(shardStr)
+	if !found || err != nil || shard < 1 {
+		return 0, "", fmt.Errorf("invalid scan cursor: %q", cursor)
+	}
+	return shard, startAfter, nil
+}
+
+/*
+ * Returns up to `limit` unexpired entries whose keys start with `prefix`, and a
+ * cursor to pass to the next call to continue after them ("" to start from the
+ * beginning). The returned cursor is "" once every shard has been scanned.
+ *
+ * Shards are walked in order, and keys within a shard in ascending order, so
+ * entries are not globally sorted. Each shard is read from a single replica, picked
+ * round-robin like Get and failing over to the others. Shards without any replicas
+ * are skipped. Keys written or deleted while a scan is in progress may or may not
+ * be seen.
+ *
+ * If a shard cannot be read from any replica, the entries gathered so far are
+ * returned with a cursor pointing at that shard; if there are none, the error is
+ * returned and the same cursor can be retried later.
+ */
+func (kv *Kv) Scan(ctx context.Context, prefix string, cursor string, limit int) ([]ScanEntry, string, error) {
+	shard, startAfter, err := decodeScanCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = defaultScanLimit
+	}
+
+	numShards := kv.shardMap.NumShards()
+	entries := make([]ScanEntry, 0)
+	for shard <= numShards && len(entries) < limit {
+		response, err := kv.scanShard(ctx, shard, prefix, startAfter, limit-len(entries))
+		if err != nil {
+			if len(entries) > 0 {
+				break
+			}
+			return nil, "", err
+		}
+		for _, value := range response.Values {
+			entries = append(entries, ScanEntry{Key: string(value.Key), Value: string(value.Value)})
+		}
+		if response.More && len(response.Values) > 0 {
+			startAfter = string(response.Values[len(response.Values)-1].Key)
+		} else {
+			shard++
+			startAfter = ""
+		}
+	}
+
+	if shard > numShards {
+		return entries, "", nil
+	}
+	return entries, encodeScanCursor(shard, startAfter), nil
+}
+
+func (kv *Kv) scanShard(ctx context.Context, shard int, prefix string, startAfter string, limit int) (*proto.ScanResponse, error) {
+	nodes := kv.replicaOrder(shard)
+	if len(nodes) == 0 {
+		return &proto.ScanResponse{}, nil
+	}
+
+	var lastErr error
+	for _, node := range nodes {
+		client, err := kv.clientPool.GetClient(node)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		response, err := client.Scan(ctx, &proto.ScanRequest{
+			Shard:      int32(shard),
+			Prefix:     []byte(prefix),
+			StartAfter: []byte(startAfter),
+			Limit:      int32(limit),
+		})
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+/*
+ * Iterates over all entries matching a prefix, fetching pages with Kv.Scan as
+ * needed:
+ *
+ *	it := client.NewScanIterator(ctx, "user:", "", 100)
+ *	for it.Next() {
+ *		entry := it.Entry()
+ *		...
+ *	}
+ *	if err := it.Err(); err != nil {
+ *		// it.Cursor() can be used to resume later
+ *	}
+ */
+type ScanIterator struct {
+	kv       *Kv
+	ctx      context.Context
+	prefix   string
+	pageSize int
+
+	page    []ScanEntry
+	pos     int
+	cursor  string // cursor for the page after `page`
+	done    bool   // no pages left after `page`
+	current *ScanEntry
+	err     error
+}
+
+/*
+ * `cursor` is where to start ("" for the beginning), e.g. a value previously
+ * returned by Cursor().
+ */
+func (kv *Kv) NewScanIterator(ctx context.Context, prefix string, cursor string, pageSize int) *ScanIterator {
+	return &ScanIterator{
+		kv:       kv,
+		ctx:      ctx,
+		prefix:   prefix,
+		pageSize: pageSize,
+		cursor:   cursor,
+	}
+}
+
+/*
+ * Advances to the next entry, returning false once there are none left or an
+ * error occurred (see Err).
+ */
+func (it *ScanIterator) Next() bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, next, err := it.kv.Scan(it.ctx, it.prefix, it.cursor, it.pageSize)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.pos, it.cursor = page, 0, next
+		it.done = next == ""
+	}
+	it.current = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+func (it *ScanIterator) Entry() ScanEntry {
+	return *it.current
+}
+
+func (it *ScanIterator) Err() error {
+	return it.err
+}
+
+/*
+ * A cursor which resumes the scan right after the entry last returned by Next,
+ * or "" if the scan has finished.
+ */
+func (it *ScanIterator) Cursor() string {
+	if it.pos < len(it.page) {
+		shard := GetShardForKey(it.current.Key, it.kv.shardMap.NumShards())
+		return encodeScanCursor(shard, it.current.Key)
+	}
+	if it.done {
+		return ""
+	}
+	return it.cursor
+}
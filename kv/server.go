@@ -1,23 +1,108 @@
 package kv
 
 import (
-	"container/heap"
+	"container/list"
 	"context"
-	"math/rand"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"cs426.yale.edu/lab4/kv/proto"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
 )
 
+/*
+ * key, value, ttl and version never change once an entry is stored (a new entry
+ * replaces it instead), so shard snapshots can share entries with the live store.
+ */
 type entry struct {
 	key   string
 	value string
 	ttl   uint64
-	index int
+	// incremented on every write to the key, survives shard copies
+	version uint64
+	// hybrid logical clock timestamp of the write (see hlc.go). Unlike versions,
+	// replicas of a key agree on it, so reads compare replicas by it (see
+	// Kv.GetWithVersion), and replicas ignore Sets older than the value they have.
+	timestamp uint64
+	// a tombstone, left by a delete at `timestamp` so older writes, replicas and
+	// shard copies do not bring the key back. Its ttl is the end of the grace
+	// period, after which Clean drops it like an expired value.
+	deleted bool
+
+	// links into the shard's expiry timing wheel (see timingwheel.go)
+	wheelSlot            *wheelSlot
+	wheelPrev, wheelNext *entry
+
+	// bookkeeping for the eviction policies (see eviction.go)
+	lruElement *list.Element
+	frequency  uint64
+	lfuIndex   int
+}
+
+// Bytes an entry counts against the memory budget
+func (e *entry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// Whether e holds a value clients can read at `now` (in unix ms)
+func (e *entry) live(now uint64) bool {
+	return !e.deleted && e.ttl >= now
+}
+
+// entry.ttl of a key which never expires (see Persist). Sorts after every real
+// expiration time; such entries are not scheduled in the timing wheel.
+const noExpiry = math.MaxUint64
+
+// Granularity of the expiry timing wheels, and how often Clean expires entries
+const expiryTick = time.Second
+
+const defaultTombstoneGracePeriod = time.Hour
+
+const (
+	defaultShardCopyParallelism = 4
+	defaultShardCopyTimeout     = time.Minute
+)
+
+// Form in which entries are sent to other nodes (shard copies, Scan, ReplicaWrite)
+func shardValue(e *entry, now uint64) *proto.GetShardValue {
+	value := &proto.GetShardValue{Key: []byte(e.key), Value: []byte(e.value), Version: e.version, Timestamp: e.timestamp, Deleted: e.deleted}
+	value.TtlMsRemaining, value.NoExpiry = ttlRemaining(e, now)
+	return value
+}
+
+// Inverse of shardValue
+func entryFromShardValue(value *proto.GetShardValue, now uint64) *entry {
+	e := &entry{
+		key:       string(value.Key),
+		value:     string(value.Value),
+		ttl:       now + uint64(value.TtlMsRemaining),
+		version:   value.Version,
+		timestamp: value.Timestamp,
+		deleted:   value.Deleted,
+	}
+	if value.NoExpiry {
+		e.ttl = noExpiry
+	}
+	return e
+}
+
+// Time left before e expires, as sent over the wire: in ms, or no expiry at all
+func ttlRemaining(e *entry, now uint64) (int64, bool) {
+	if e.ttl == noExpiry {
+		return 0, true
+	}
+	return int64(e.ttl) - int64(now), false
 }
 
 type KvServerImpl struct {
@@ -29,19 +114,203 @@ type KvServerImpl struct {
 	clientPool ClientPool
 	shutdown   chan struct{}
 
-	data        []map[string]*entry
+	// nil for shards which have never been hosted (or recovered)
+	data        []ShardStore
 	locks       []sync.RWMutex
 	cleanupTick *time.Ticker
 
 	hostedShards map[int]bool
-	shardLock    sync.RWMutex
+	// shards newly assigned to us which are still being copied in: they accept
+	// blind writes (see checkShardWritable), which the copy is merged under
+	receivingShards map[int]bool
+	shardLock       sync.RWMutex
+	// most shards copied in at once, and how long each copy may take (see
+	// KvServerOptions.ShardCopyParallelism and ShardCopyTimeout)
+	shardCopyParallelism int
+	shardCopyTimeout     time.Duration
+
+	// creates empty stores for the configured storage engine
+	newStore func() ShardStore
+
+	// nil unless the server was started with a data directory
+	persistence *persistence
+	// closed once persistenceLoop has returned
+	persistenceDone chan struct{}
+	// shards recovered from disk which have not been reconciled with the ShardMap yet
+	recoveredShards map[int]bool
+
+	// bytes of keys+values stored, per shard (guarded by the shard lock) and in total
+	shardBytes  []int64
+	memoryBytes atomic.Int64
+	// changes to each shard's store (guarded by the shard lock), which tell
+	// when a cached Merkle tree is out of date (see shardMerkleTree)
+	shardChanges []uint64
+	merkleTrees  merkleTreeCache
+	// 0 means unlimited, in which case eviction is nil
+	memoryBudget int64
+	eviction     []evictionPolicy
+	// signals evictionLoop that the budget is exceeded
+	evictNeeded chan struct{}
+
+	stats Stats
+
+	// stamps the writes clients send without a timestamp (see hlc.go)
+	clock hybridClock
+
+	// active Watch streams
+	watches watchHub
+
+	// writes to forward to replicas which missed them (see hints.go)
+	hints *hintStore
+
+	// how long tombstones are kept (see KvServerOptions.TombstoneGracePeriod)
+	tombstoneGrace time.Duration
+
+	// primary-backup replication (see primary.go)
+	primaryBackup bool
+	keyLocks      keyLocks
+	// chain replication (see chain.go), which also uses keyLocks
+	chainReplication bool
 
-	heaps []EntryHeap
+	// nil unless shards are replicated by Raft (see raftshards.go)
+	raft *raftShards
+
+	// status of the latest copy of each shard (see migration.go)
+	migrations shardMigrations
+}
+
+// Chunk size requested from peers when streaming a shard during migrations,
+// well under gRPC's default 4MB message limit
+const shardCopyChunkBytes = 1 << 20
+
+// page size for Scan when the request does not give one, and the most it may ask for
+const (
+	defaultScanLimit = 100
+	maxScanLimit     = 10000
+)
+
+type KvServerOptions struct {
+	// If set, every write is logged to a write-ahead log in this directory and
+	// shards are periodically snapshotted there, so a restarted server recovers
+	// its data. If empty, the server is purely in-memory. In Raft mode, holds
+	// the Raft groups' logs instead (see raftlog.go).
+	DataDir string
+	// How often shards are snapshotted to DataDir. Defaults to one minute.
+	SnapshotInterval time.Duration
+	// If > 0, the server keeps the bytes of keys+values it stores under this budget
+	// by evicting entries chosen by EvictionPolicy. Writes never fail because of it.
+	MemoryBudgetBytes int64
+	// One of EvictLRU (default), EvictLFU or EvictTTLSoonest.
+	EvictionPolicy string
+	// How shards are stored in memory: StorageMap (default) or StorageBTree.
+	StorageEngine string
+	// Most hints for other replicas the server holds at once (see hints.go).
+	// Defaults to 10000.
+	MaxHints int
+	// How long a hint is kept while its node is unreachable. Defaults to an hour.
+	HintTTL time.Duration
+	// How long a deleted key is remembered (see entry.deleted), which bounds
+	// how late a replica or shard copy which missed the delete may catch up
+	// without bringing the key back. Defaults to an hour.
+	TombstoneGracePeriod time.Duration
+	// Most newly assigned shards copied from other nodes at once after a
+	// ShardMap update. Defaults to 4.
+	ShardCopyParallelism int
+	// How long copying a shard from one node may take before the next node is
+	// tried. Defaults to one minute.
+	ShardCopyTimeout time.Duration
+	// If > 0, how often the server compares each shard it hosts with another
+	// replica and syncs the keys they disagree on (see antientropy.go).
+	AntiEntropyInterval time.Duration
+	// Most keys anti-entropy syncs per second. Defaults to 1000.
+	AntiEntropyKeysPerSecond int
+	// If set, the first node of each shard in the ShardMap is its primary, which
+	// alone accepts client writes and replicates them to the others (see
+	// primary.go). Clients must set KvOptions.PrimaryBackup to match.
+	PrimaryBackup bool
+	// If set, each shard is replicated by a Raft group of the nodes hosting it,
+	// making operations linearizable (see raftshards.go). Clients must set
+	// KvOptions.Raft to match. Requires DataDir. Cannot be combined with
+	// MemoryBudgetBytes, AntiEntropyInterval or PrimaryBackup.
+	Raft bool
+	// If set, the nodes of each shard in the ShardMap form a chain: client writes
+	// go to the first node and are passed down to the last, which alone serves
+	// reads (see chain.go). Clients must set KvOptions.ChainReplication to
+	// match. Cannot be combined with PrimaryBackup or Raft.
+	ChainReplication bool
+}
+
+/*
+ * Replaces any entry with the same key. Tombstones are kept outside the memory
+ * budget and the eviction policies: evicting one before the tombstone grace
+ * period ends would let a replica which missed the delete bring the key back.
+ *
+ * NOTE: must hold the (write) lock for the shard.
+ */
+func (server *KvServerImpl) putEntry(shard int, e *entry) {
+	if old, exists := server.data[shard-1].get(e.key); exists {
+		server.removeEntry(shard, old)
+	}
+	server.data[shard-1].set(e)
+	server.shardChanges[shard-1]++
+	if e.deleted {
+		return
+	}
+	server.shardBytes[shard-1] += e.size()
+	server.memoryBytes.Add(e.size())
+	if server.eviction != nil {
+		server.eviction[shard-1].added(e)
+	}
+}
+
+// NOTE: must hold the (write) lock for the shard
+func (server *KvServerImpl) removeEntry(shard int, e *entry) {
+	// no-op if the store already dropped it (see expireShard)
+	server.data[shard-1].delete(e.key)
+	server.shardChanges[shard-1]++
+	if e.deleted {
+		return
+	}
+	server.shardBytes[shard-1] -= e.size()
+	server.memoryBytes.Add(-e.size())
+	if server.eviction != nil {
+		server.eviction[shard-1].removed(e)
+	}
+}
+
+// NOTE: must hold the lock for the shard
+func (server *KvServerImpl) clearShard(shard int) {
+	if server.data[shard-1] == nil {
+		server.data[shard-1] = server.newStore()
+	} else {
+		server.data[shard-1].clear()
+	}
+	server.shardChanges[shard-1]++
+	server.memoryBytes.Add(-server.shardBytes[shard-1])
+	server.shardBytes[shard-1] = 0
+	if server.eviction != nil {
+		server.eviction[shard-1].reset()
+	}
+	if server.persistence != nil {
+		if err := server.persistence.logClearShard(shard); err != nil {
+			logrus.WithField("shard", shard).Errorf("failed to log shard removal: %q", err)
+		}
+	}
 }
 
 func (server *KvServerImpl) handleShardMapUpdate() {
+	if server.raft != nil {
+		// groups replicate shards to their new nodes themselves
+		server.updateRaftGroups()
+		return
+	}
 	// TODO: Part C
 	server.shardLock.Lock()
+	if server.data == nil {
+		// shut down (see Clean) while the update was pending
+		server.shardLock.Unlock()
+		return
+	}
 	logrus.Debugf("(handleShardMapUpdate): KvServerImpl %s updating shardMap", server.nodeName)
 	updatedShards := server.shardMap.ShardsForNode(server.nodeName)
 	oldShards := server.hostedShards
@@ -58,84 +327,65 @@ func (server *KvServerImpl) handleShardMapUpdate() {
 		}
 	}
 
-	for i := 0; i < len(addNodes); i++ {
-		shard := addNodes[i]
-		StN := server.shardMap.GetState().ShardsToNodes[shard]
-		rIdx := rand.Intn(len(StN))
-		logrus.Debugln("(handleShardMapUpdate): rIdx: ", rIdx)
-		lastErr := false
-		for j := 0; j < len(StN); j++ {
-			node := StN[(rIdx+j)%len(StN)]
-			if node == server.nodeName {
-				continue
-			}
-			client, err := server.clientPool.GetClient(node)
-			if err != nil {
-				logrus.Debugln("(handleShardMapUpdate): GetClient error: ", err)
-				continue
-			}
-			server.shardLock.Unlock()
-			newKVR, err := client.GetShardContents(context.Background(), &proto.GetShardContentsRequest{Shard: int32(shard)})
-			server.shardLock.Lock()
-			if err != nil {
-				logrus.Debugln("(handleShardMapUpdate): GetShardContents error: ", err)
-				continue
-			}
-			logrus.Debugln("(handleShardMapUpdate): acquiring lock for shard ", shard-1)
-			server.locks[shard-1].Lock()
-
-			server.data[shard-1] = make(map[string]*entry)
-			server.heaps[shard-1] = make(EntryHeap, 0)
-			heap.Init(&server.heaps[shard-1])
-
-			newKV := newKVR.Values
-			for j := 0; j < len(newKV); j++ {
-				key := newKV[j].Key
-				value := newKV[j].Value
-				ttl := uint64(time.Now().UnixMilli()) + uint64(newKV[j].TtlMsRemaining)
-				// server.data[shard-1][key] = &entry{value: value, ttl: ttl}
-				newEntry := &entry{
-					key:   key,
-					value: value,
-					ttl:   ttl,
-				}
-				server.data[shard-1][key] = newEntry
-				heap.Push(&server.heaps[shard-1], newEntry)
-				logrus.Debugln("(handleShardMapUpdate): Adding key ", key, " to shard ", shard, " with value ", value, " and ttl ", ttl)
-			}
-			logrus.Debugln("(handleShardMapUpdate): releasing lock for shard ", shard-1)
-			server.locks[shard-1].Unlock()
-			lastErr = true
-			break
-		}
-		if !lastErr {
+	// start accepting writes for the new shards right away; their contents are
+	// merged in as the copies land
+	server.receivingShards = make(map[int]bool, len(addNodes))
+	for _, shard := range addNodes {
+		if !server.recoveredShards[shard] {
 			server.locks[shard-1].Lock()
-			server.data[shard-1] = make(map[string]*entry)
-			server.heaps[shard-1] = make(EntryHeap, 0)
-			heap.Init(&server.heaps[shard-1])
+			server.clearShard(shard)
 			server.locks[shard-1].Unlock()
 		}
+		server.receivingShards[shard] = true
+	}
 
+	// copy them in parallel, without holding shardLock, and serve each one as
+	// soon as its copy is done
+	recoveredShards := server.recoveredShards
+	server.shardLock.Unlock()
+	copies := semaphore.NewWeighted(int64(server.shardCopyParallelism))
+	var wg sync.WaitGroup
+	for _, shard := range addNodes {
+		// cannot fail: the context is never cancelled
+		_ = copies.Acquire(context.Background(), 1)
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			defer copies.Release(1)
+			server.receiveShard(shard, recoveredShards[shard])
+		}(shard)
 	}
+	wg.Wait()
 
+	// old shards are cleared under shardLock too, so Clean cannot wipe the
+	// data in the meantime
+	server.shardLock.Lock()
+	defer server.shardLock.Unlock()
+	if server.data == nil {
+		return
+	}
 	server.hostedShards = newShards
-	logrus.Debugln("(handleShardMapUpdate): releasing shardLock")
-	server.shardLock.Unlock()
+	server.recoveredShards = nil
 	deleteNodes := make([]int, 0)
 	for shard := range oldShards {
 		if !newShards[shard] {
 			deleteNodes = append(deleteNodes, shard)
 		}
 	}
+	for shard := range recoveredShards {
+		if !newShards[shard] && !oldShards[shard] {
+			deleteNodes = append(deleteNodes, shard)
+		}
+	}
 	logrus.Debugln("(handleShardMapUpdate): deleting old shards...")
 	for i := 0; i < len(deleteNodes); i++ {
 		shard := deleteNodes[i]
+		// stop any retried copy first, so it does not refill the shard
+		server.migrations.forget(shard)
 		server.locks[shard-1].Lock()
-		// Clear the data map for the shard
-		server.data[shard-1] = make(map[string]*entry)
-		// Clear and reinitialize the heap for the shard
-		server.heaps[shard-1] = make(EntryHeap, 0)
-		heap.Init(&server.heaps[shard-1])
+		// Clear the data map and timing wheel for the shard
+		server.clearShard(shard)
+		server.watches.closeShard(shard, status.Error(codes.NotFound, "Shard moved off this server"))
 		// for i := range server.data[shard-1] {
 		// shard2 := GetShardForKey(i, server.shardMap.NumShards())
 		// if shard2 == shard {
@@ -144,7 +394,250 @@ func (server *KvServerImpl) handleShardMapUpdate() {
 		// }
 		server.locks[shard-1].Unlock()
 	}
-	logrus.Debugln("(handleShardMapUpdate): deleted old shards; updated complete")
+	logrus.Debugln("(handleShardMapUpdate): deleted old shards; updated complete; releasing shardLock")
+}
+
+/*
+ * Copies a newly assigned shard from one of its other nodes, trying each in
+ * turn from a random one, then starts serving it. Called without shardLock.
+ */
+func (server *KvServerImpl) receiveShard(shard int, recovered bool) {
+	migration, ctx, _ := server.migrations.begin(shard, true)
+	state := server.copyShard(ctx, migration, shard, fromRandomNode(server.shardMap.NodesForShard(shard)))
+	if state != proto.ShardMigrationStatus_ACTIVE && recovered {
+		// no peer could give us the shard, but we still have our own copy from disk
+		logrus.Debugln("(handleShardMapUpdate): keeping recovered data for shard ", shard)
+		if state == proto.ShardMigrationStatus_NO_PEER {
+			// which is all there is
+			server.migrations.finish(migration, proto.ShardMigrationStatus_ACTIVE, "")
+		}
+	}
+
+	// serve it from now on (a copy of the map, since handleShardMapUpdate still
+	// uses the old one)
+	server.shardLock.Lock()
+	defer server.shardLock.Unlock()
+	hosted := make(map[int]bool, len(server.hostedShards)+1)
+	for hostedShard := range server.hostedShards {
+		hosted[hostedShard] = true
+	}
+	hosted[shard] = true
+	server.hostedShards = hosted
+	delete(server.receivingShards, shard)
+}
+
+/*
+ * Merges a copy of `shard` from `node` into what we have, giving up when ctx
+ * is done, and counts the entries received in `migration`. The copy is
+ * streamed and each chunk is applied as it arrives; peers which do not support
+ * streaming are asked for the whole shard with GetShardContents instead.
+ *
+ * The shard is receiving (see receivingShards) or already served (a retried
+ * copy), so writes may have reached it since the copy started: each copied
+ * entry is only stored if it is newer than our value or tombstone for its key
+ * (see newerValue), so later local writes win, and merging the same entries
+ * again after a failed copy is harmless.
+ */
+func (server *KvServerImpl) copyShardFrom(ctx context.Context, shard int, node string, migration *shardMigration) error {
+	client, err := server.clientPool.GetClient(node)
+	if err != nil {
+		return err
+	}
+
+	apply := func(values []*proto.GetShardValue) error {
+		server.locks[shard-1].Lock()
+		defer server.locks[shard-1].Unlock()
+		// checked under the lock: once the copy is cancelled because the shard
+		// moved off the node, it must not refill the shard after it is cleared
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		now := uint64(time.Now().UnixMilli())
+		var bytes int64
+		for _, value := range values {
+			bytes += int64(len(value.Key) + len(value.Value))
+			server.clock.update(value.Timestamp)
+			current, exists := server.data[shard-1].get(string(value.Key))
+			if exists && !newerValue(value.Timestamp, value.Version, current.timestamp, current.version) {
+				server.stats.Add("shard_copy_keys_superseded", 1)
+				server.versionPast(shard, current, value.Version)
+				continue
+			}
+			newEntry := entryFromShardValue(value, now)
+			server.putEntry(shard, newEntry)
+			if server.persistence != nil {
+				if err := server.persistence.logSet(shard, newEntry); err != nil {
+					logrus.WithField("shard", shard).Errorf("failed to log copied key: %q", err)
+				}
+			}
+		}
+		server.migrations.copied(migration, int64(len(values)), bytes)
+		return nil
+	}
+
+	stream, err := client.StreamShardContents(ctx, &proto.StreamShardContentsRequest{Shard: int32(shard), MaxChunkBytes: shardCopyChunkBytes})
+	if status.Code(err) == codes.Unimplemented {
+		response, err := client.GetShardContents(ctx, &proto.GetShardContentsRequest{Shard: int32(shard)})
+		if err != nil {
+			return err
+		}
+		return apply(response.Values)
+	}
+	if err != nil {
+		return err
+	}
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if status.Code(err) == codes.Unimplemented && chunks == 0 {
+			// gRPC only reports unknown methods once we start reading
+			response, err := client.GetShardContents(ctx, &proto.GetShardContentsRequest{Shard: int32(shard)})
+			if err != nil {
+				return err
+			}
+			return apply(response.Values)
+		}
+		if err != nil {
+			return err
+		}
+		chunks++
+		if err := apply(chunk.Values); err != nil {
+			return err
+		}
+		server.signalEviction()
+	}
+	logrus.Debugln("(copyShardFrom): copied shard ", shard, " from ", node, " in ", chunks, " chunks")
+	return nil
+}
+
+/*
+ * Our entry won the merge with a copied one of `version`, i.e. our write came
+ * after it, but may have been counted from a lower version if the key was new
+ * here (writes reach a shard while it is copied in). Replicas holding the
+ * copied entry applied the write on top of it, so ours takes the version they
+ * have, as they would do: one more for a value, the same for a tombstone (see
+ * deleteEntry). Keeps versions increasing, and replicas agreeing on them.
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) versionPast(shard int, current *entry, version uint64) {
+	if !current.deleted {
+		version++
+	}
+	if version <= current.version {
+		return
+	}
+	bumped := &entry{
+		key:       current.key,
+		value:     current.value,
+		ttl:       current.ttl,
+		version:   version,
+		timestamp: current.timestamp,
+		deleted:   current.deleted,
+	}
+	server.putEntry(shard, bumped)
+	if server.persistence != nil {
+		if err := server.persistence.logSet(shard, bumped); err != nil {
+			logrus.WithField("shard", shard).Errorf("failed to log copied key: %q", err)
+		}
+	}
+}
+
+/*
+ * Evicts entries from `shard` while the node is over its memory budget. `keep` (the
+ * entry just written) is never evicted, so a write always succeeds even if it alone
+ * exceeds the budget. If this shard runs out of entries to evict, the remaining
+ * overshoot is left to evictionLoop, which can take other shards' locks.
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) evictIfNeeded(shard int, keep *entry) {
+	if server.eviction == nil {
+		return
+	}
+	for server.memoryBytes.Load() > server.memoryBudget {
+		victim := server.eviction[shard-1].victim()
+		if victim == nil || victim == keep {
+			server.signalEviction()
+			return
+		}
+		server.evict(shard, victim)
+	}
+}
+
+// NOTE: must hold the (write) lock for the shard
+func (server *KvServerImpl) evict(shard int, victim *entry) {
+	if server.persistence != nil {
+		// otherwise the key would come back after a restart
+		if err := server.persistence.logDelete(shard, victim.key); err != nil {
+			logrus.WithField("shard", shard).Errorf("failed to log eviction: %q", err)
+		}
+	}
+	server.removeEntry(shard, victim)
+	server.watches.publish(shard, proto.WatchEvent_EVICT, victim.key, "", 0)
+	server.stats.Add("evictions", 1)
+	server.stats.Add("evicted_bytes", victim.size())
+	logrus.Traceln("(evict): evicted key ", victim.key, " from shard ", shard)
+}
+
+func (server *KvServerImpl) signalEviction() {
+	if server.eviction == nil {
+		return
+	}
+	select {
+	case server.evictNeeded <- struct{}{}:
+	default:
+	}
+}
+
+/*
+ * Brings the node back under its memory budget when a single shard could not,
+ * e.g. after a shard copy or when most data lives in shards that are rarely written.
+ * Shards are visited from the largest to the smallest.
+ */
+func (server *KvServerImpl) evictionLoop() {
+	for {
+		select {
+		case <-server.shutdown:
+			return
+		case <-server.evictNeeded:
+		}
+		if server.memoryBytes.Load() <= server.memoryBudget {
+			continue
+		}
+		server.shardLock.RLock()
+		if server.data == nil {
+			server.shardLock.RUnlock()
+			return
+		}
+		shards := make([]int, len(server.data))
+		sizes := make([]int64, len(server.data))
+		for i := range shards {
+			shards[i] = i + 1
+			server.locks[i].RLock()
+			sizes[i] = server.shardBytes[i]
+			server.locks[i].RUnlock()
+		}
+		sort.Slice(shards, func(a, b int) bool { return sizes[shards[a]-1] > sizes[shards[b]-1] })
+		for _, shard := range shards {
+			if server.memoryBytes.Load() <= server.memoryBudget {
+				break
+			}
+			server.locks[shard-1].Lock()
+			for server.memoryBytes.Load() > server.memoryBudget {
+				victim := server.eviction[shard-1].victim()
+				if victim == nil {
+					break
+				}
+				server.evict(shard, victim)
+			}
+			server.locks[shard-1].Unlock()
+		}
+		server.shardLock.RUnlock()
+	}
 }
 
 func (server *KvServerImpl) shardMapListenLoop() {
@@ -169,10 +662,8 @@ func (server *KvServerImpl) Clean() {
 			logrus.Debugln("(Clean): Wiping server data")
 			for i := range server.data {
 				server.locks[i].Lock()
-				server.heaps[i] = nil
-
-				for k := range server.data[i] {
-					delete(server.data[i], k)
+				if server.data[i] != nil {
+					server.data[i].clear()
 				}
 				server.data[i] = nil
 
@@ -189,54 +680,142 @@ func (server *KvServerImpl) Clean() {
 			server.locks = nil
 			return
 		case <-server.cleanupTick.C:
-			server.shardLock.Lock()
 			for i := 0; i < len(server.data); i++ {
-				current := uint64(time.Now().UnixMilli())
-				server.locks[i].Lock()
-				h := &server.heaps[i]
-				for h.Len() > 0 {
-					entry := (*h)[0] // Peek at the top of the heap
-					if entry.ttl > current {
-						// The earliest ttl is in the future, stop cleaning
-						break
-					}
-
-					// Remove from heap
-					heap.Pop(h)
-					// Remove from map
-					delete(server.data[i], entry.key)
-					logrus.Debugln("(Clean): Deleted expired key", entry.key, "from shard", i+1)
-				}
-				// for key, value := range server.data[i] {
-				// 	// println(value.ttl)
-				// 	// println)
-				// 	current := uint64(time.Now().UnixMilli())
-				// 	if value.ttl < current {
-				// 		logrus.Debugln("(Clean): Deleting key ", key, " with ttl ", value.ttl, " from shard ", i, " at time ", current)
-				// 		delete(server.data[i], key)
-				// 	}
-				// }
-				server.locks[i].Unlock()
+				server.expireShard(i + 1)
 			}
-			server.shardLock.Unlock()
-			// time.Sleep(1 * time.Second)
 		}
 	}
 }
 
+/*
+ * Removes the entries of `shard` whose TTL has passed since the last call. Only the
+ * shard's own lock is held (which is enough, since clearing or replacing a shard
+ * also needs it), so a shard with many expiring keys does not stall the others.
+ */
+func (server *KvServerImpl) expireShard(shard int) {
+	server.locks[shard-1].Lock()
+	defer server.locks[shard-1].Unlock()
+	store := server.data[shard-1]
+	if store == nil {
+		return
+	}
+	for _, e := range store.expire(uint64(time.Now().UnixMilli())) {
+		server.removeEntry(shard, e)
+		if e.deleted {
+			// past its grace period
+			server.stats.Add("tombstones_collected", 1)
+			continue
+		}
+		server.watches.publish(shard, proto.WatchEvent_EXPIRE, e.key, "", 0)
+		logrus.Debugln("(Clean): Deleted expired key", e.key, "from shard", shard)
+	}
+}
+
 func MakeKvServer(nodeName string, shardMap *ShardMap, clientPool ClientPool) *KvServerImpl {
+	// cannot fail without a data directory
+	server, _ := MakeKvServerWithOptions(nodeName, shardMap, clientPool, KvServerOptions{})
+	return server
+}
+
+func MakeKvServerWithOptions(nodeName string, shardMap *ShardMap, clientPool ClientPool, options KvServerOptions) (*KvServerImpl, error) {
+	newStore, err := shardStoreFactory(options.StorageEngine)
+	if err != nil {
+		return nil, err
+	}
+	if options.Raft && options.DataDir == "" {
+		return nil, errors.New("Raft mode requires a data directory")
+	}
+	if options.Raft && (options.MemoryBudgetBytes > 0 || options.AntiEntropyInterval > 0 || options.PrimaryBackup) {
+		return nil, errors.New("Raft mode cannot be combined with a memory budget, anti-entropy or primary-backup replication")
+	}
+	if options.ChainReplication && (options.PrimaryBackup || options.Raft) {
+		return nil, errors.New("chain replication cannot be combined with primary-backup replication or Raft")
+	}
+	data := make([]ShardStore, shardMap.NumShards())
+	var eviction []evictionPolicy
+	if options.MemoryBudgetBytes > 0 {
+		eviction = make([]evictionPolicy, shardMap.NumShards())
+		for i := range eviction {
+			store := &data[i]
+			soonest := func() *entry {
+				if *store == nil {
+					return nil
+				}
+				return (*store).soonest()
+			}
+			policy, err := newEvictionPolicy(options.EvictionPolicy, soonest)
+			if err != nil {
+				return nil, err
+			}
+			eviction[i] = policy
+		}
+	}
+
+	var p *persistence
+	var recovered []map[string]*entry
+	if options.DataDir != "" && !options.Raft {
+		var err error
+		p, err = openPersistence(options.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		recovered, err = p.recover(shardMap.NumShards())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	listener := shardMap.MakeListener()
 	server := KvServerImpl{
-		nodeName:    nodeName,
-		shardMap:    shardMap,
-		listener:    &listener,
-		clientPool:  clientPool,
-		shutdown:    make(chan struct{}),
-		data:        make([]map[string]*entry, shardMap.NumShards()),
-		locks:       make([]sync.RWMutex, shardMap.NumShards()),
-		cleanupTick: time.NewTicker(3 * time.Second),
-		shardLock:   sync.RWMutex{},
-		heaps:       make([]EntryHeap, shardMap.NumShards()),
+		nodeName:     nodeName,
+		shardMap:     shardMap,
+		listener:     &listener,
+		clientPool:   clientPool,
+		shutdown:     make(chan struct{}),
+		data:         data,
+		locks:        make([]sync.RWMutex, shardMap.NumShards()),
+		cleanupTick:  time.NewTicker(expiryTick),
+		shardLock:    sync.RWMutex{},
+		newStore:     newStore,
+		persistence:  p,
+		shardBytes:   make([]int64, shardMap.NumShards()),
+		shardChanges: make([]uint64, shardMap.NumShards()),
+		evictNeeded:  make(chan struct{}, 1),
+
+		memoryBudget:     options.MemoryBudgetBytes,
+		eviction:         eviction,
+		hints:            newHintStore(options.MaxHints, options.HintTTL),
+		tombstoneGrace:   options.TombstoneGracePeriod,
+		primaryBackup:    options.PrimaryBackup,
+		chainReplication: options.ChainReplication,
+
+		shardCopyParallelism: options.ShardCopyParallelism,
+		shardCopyTimeout:     options.ShardCopyTimeout,
+	}
+	if server.tombstoneGrace <= 0 {
+		server.tombstoneGrace = defaultTombstoneGracePeriod
+	}
+	if server.shardCopyParallelism <= 0 {
+		server.shardCopyParallelism = defaultShardCopyParallelism
+	}
+	if server.shardCopyTimeout <= 0 {
+		server.shardCopyTimeout = defaultShardCopyTimeout
+	}
+	if options.Raft {
+		server.raft = &raftShards{dataDir: options.DataDir, groups: make(map[int]*raftGroup)}
+	}
+	if recovered != nil {
+		server.recoveredShards = make(map[int]bool)
+		for i := range recovered {
+			if recovered[i] == nil {
+				continue
+			}
+			server.recoveredShards[i+1] = true
+			server.data[i] = server.newStore()
+			for _, e := range recovered[i] {
+				server.putEntry(i+1, e)
+			}
+		}
 	}
 
 	// for i := 0; i < len(server.data); i++ {
@@ -247,10 +826,34 @@ func MakeKvServer(nodeName string, shardMap *ShardMap, clientPool ClientPool) *K
 	go server.shardMapListenLoop()
 	server.handleShardMapUpdate()
 	go server.Clean()
-	return &server
+	go server.hintLoop()
+	if server.raft != nil {
+		go server.raftLoop()
+	}
+	if options.AntiEntropyInterval > 0 {
+		go server.antiEntropyLoop(options.AntiEntropyInterval, options.AntiEntropyKeysPerSecond)
+	}
+	if server.eviction != nil {
+		go server.evictionLoop()
+		server.signalEviction()
+	}
+	if server.persistence != nil {
+		snapshotInterval := options.SnapshotInterval
+		if snapshotInterval <= 0 {
+			snapshotInterval = time.Minute
+		}
+		server.persistenceDone = make(chan struct{})
+		go server.persistenceLoop(snapshotInterval)
+	}
+	return &server, nil
 }
 
 func (server *KvServerImpl) Shutdown() {
+	if server.raft != nil {
+		// before the data is wiped, so nothing is applied to it after
+		server.stopRaftGroups()
+	}
+	server.migrations.stop()
 	server.shutdown <- struct{}{}
 	// server.shardLock.Lock()
 	// server.cleanupTick.Stop()
@@ -267,10 +870,19 @@ func (server *KvServerImpl) Shutdown() {
 	// }
 	// server.shardLock.Unlock()
 	server.listener.Close()
+	server.watches.closeAll(status.Error(codes.Unavailable, "Server shutting down"))
 	// // runtime.GC()
 	// server.heaps = nil
 	// server.locks = nil
 	close(server.shutdown)
+	if server.persistence != nil {
+		// a checkpoint may still be writing snapshots: let it finish before
+		// closing the WAL, so nothing touches the data directory after we return
+		<-server.persistenceDone
+		if err := server.persistence.close(); err != nil {
+			logrus.WithField("node", server.nodeName).Errorf("failed to close WAL: %q", err)
+		}
+	}
 }
 
 // NOTE: CALL WITHOUT HOLDING LOCK - input is shard, not key
@@ -289,6 +901,24 @@ func (server *KvServerImpl) checkShardAssignment(key string) (int, error) {
 	return shard, nil
 }
 
+/*
+ * Like checkShardAssignment, but also accepts shards which are still being
+ * copied in, for blind writes (which do not depend on the current value, so
+ * the copy can be merged under them). Reads and read-modify-writes wait for
+ * the copy, failing with NotFound like for any shard we do not host.
+ *
+ * NOTE: CALL WITHOUT HOLDING LOCK - input is key, not shard
+ */
+func (server *KvServerImpl) checkShardWritable(key string) (int, error) {
+	shard := GetShardForKey(key, server.shardMap.NumShards())
+	server.shardLock.RLock()
+	defer server.shardLock.RUnlock()
+	if !server.hostedShards[shard] && !server.receivingShards[shard] {
+		return -1, status.Errorf(codes.NotFound, "Key is not hosting within this shard/server")
+	}
+	return shard, nil
+}
+
 func (server *KvServerImpl) Get(
 	ctx context.Context,
 	request *proto.GetRequest,
@@ -305,23 +935,122 @@ func (server *KvServerImpl) Get(
 	//
 	// panic("TODO: Part A")
 
-	shard, err := server.checkShardAssignment(request.Key)
+	e, err := server.lookup(ctx, request.Key)
 	if err != nil {
 		return &proto.GetResponse{Value: "", WasFound: false}, err
 	}
+	if e == nil {
+		return &proto.GetResponse{WasFound: false}, nil
+	}
+	if e.deleted {
+		// so clients prefer the delete over older values on other replicas, and
+		// can repair them with it
+		response := &proto.GetResponse{WasFound: false, Deleted: true, Version: e.version, Timestamp: e.timestamp}
+		response.TtlMsRemaining, _ = ttlRemaining(e, uint64(time.Now().UnixMilli()))
+		return response, nil
+	}
+	if !utf8.ValidString(e.value) {
+		// would fail to marshal as a proto3 string
+		return nil, status.Error(codes.FailedPrecondition, "Value is not valid UTF-8, use GetBytes")
+	}
+
+	response := &proto.GetResponse{
+		Value:     e.value,
+		WasFound:  true,
+		Version:   e.version,
+		Timestamp: e.timestamp,
+	}
+	response.TtlMsRemaining, response.NoExpiry = ttlRemaining(e, uint64(time.Now().UnixMilli()))
+	return response, nil
+}
+
+/*
+ * Shared by Get and GetBytes: returns the live entry for `key`, its tombstone if
+ * it was deleted, or nil if there is neither. Looks it up under the shard's read
+ * lock; stored entries are never modified, so the caller may keep reading the
+ * returned one after the lock is released.
+ */
+func (server *KvServerImpl) lookup(ctx context.Context, key string) (*entry, error) {
+	shard, err := server.checkShardAssignment(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.checkReadable(ctx, shard); err != nil {
+		return nil, err
+	}
 
 	server.locks[shard-1].RLock()
 	defer server.locks[shard-1].RUnlock()
 
-	entry, exists := server.data[shard-1][request.Key]
+	entry, exists := server.data[shard-1].get(key)
 	if !exists || entry.ttl < uint64(time.Now().UnixMilli()) {
-		return &proto.GetResponse{WasFound: false}, nil
+		return nil, nil
+	}
+	if server.eviction != nil && !entry.deleted {
+		server.eviction[shard-1].accessed(entry)
+	}
+	return entry, nil
+}
+
+/*
+ * GetBytes/SetBytes/DeleteBytes behave exactly like Get/Set/Delete: keys and
+ * values are stored as Go strings, which may hold arbitrary bytes, so the two
+ * families of calls see the same data. Only the wire types differ.
+ */
+func (server *KvServerImpl) GetBytes(
+	ctx context.Context,
+	request *proto.GetBytesRequest,
+) (*proto.GetBytesResponse, error) {
+	if len(request.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	e, err := server.lookup(ctx, string(request.Key))
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return &proto.GetBytesResponse{WasFound: false}, nil
+	}
+	if e.deleted {
+		response := &proto.GetBytesResponse{WasFound: false, Deleted: true, Version: e.version, Timestamp: e.timestamp}
+		response.TtlMsRemaining, _ = ttlRemaining(e, uint64(time.Now().UnixMilli()))
+		return response, nil
 	}
+	response := &proto.GetBytesResponse{
+		Value:     []byte(e.value),
+		WasFound:  true,
+		Version:   e.version,
+		Timestamp: e.timestamp,
+	}
+	response.TtlMsRemaining, response.NoExpiry = ttlRemaining(e, uint64(time.Now().UnixMilli()))
+	return response, nil
+}
 
-	return &proto.GetResponse{
-		Value:    entry.value,
-		WasFound: true,
-	}, nil
+func (server *KvServerImpl) SetBytes(
+	ctx context.Context,
+	request *proto.SetBytesRequest,
+) (*proto.SetBytesResponse, error) {
+	_, err := server.Set(ctx, &proto.SetRequest{
+		Key:       string(request.Key),
+		Value:     string(request.Value),
+		TtlMs:     request.TtlMs,
+		Timestamp: request.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.SetBytesResponse{}, nil
+}
+
+func (server *KvServerImpl) DeleteBytes(
+	ctx context.Context,
+	request *proto.DeleteBytesRequest,
+) (*proto.DeleteBytesResponse, error) {
+	_, err := server.Delete(ctx, &proto.DeleteRequest{Key: string(request.Key), Timestamp: request.Timestamp})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DeleteBytesResponse{}, nil
 }
 
 func (server *KvServerImpl) Set(
@@ -342,36 +1071,225 @@ func (server *KvServerImpl) Set(
 		return nil, status.Error(codes.InvalidArgument, "TTL must be non-negative")
 	}
 
-	shard, err := server.checkShardAssignment(request.Key)
+	shard, err := server.checkShardWritable(request.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	server.locks[shard-1].Lock()
-	defer server.locks[shard-1].Unlock()
-	entry2, exists := server.data[shard-1][request.Key]
-	newTTL := uint64(time.Now().UnixMilli()) + uint64(request.TtlMs) // expiration timestamp
+	_, err = server.execute(ctx, shard, &proto.WriteCommand{
+		Op:        proto.WriteCommand_SET,
+		Key:       []byte(request.Key),
+		Value:     []byte(request.Value),
+		ExpiryMs:  expiryFromTtl(request.TtlMs),
+		Timestamp: server.commandTimestamp(request.Timestamp),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if exists {
-		// Remove the old entry from the heap
-		heap.Remove(&server.heaps[shard-1], entry2.index)
-		// Update the entry
-		entry2.value = request.Value
-		entry2.ttl = newTTL
-		// Re-insert into the heap
-		heap.Push(&server.heaps[shard-1], entry2)
-	} else {
-		// Create a new entry
-		entry2 = &entry{
-			key:   request.Key,
-			value: request.Value,
-			ttl:   newTTL,
+	return &proto.SetResponse{}, nil
+}
+
+// expiration timestamp for a TTL given relative to now
+func expiryFromTtl(ttlMs int64) uint64 {
+	return uint64(time.Now().UnixMilli()) + uint64(ttlMs)
+}
+
+/*
+ * Writes `value` to `key` on behalf of a client, bumping the key's version.
+ * `ttl` is the expiration timestamp, as stored in entry.ttl, and `timestamp` the
+ * write time of the command.
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) writeEntry(shard int, key string, value string, ttl uint64, timestamp uint64) (*entry, error) {
+	newEntry := &entry{
+		key:       key,
+		value:     value,
+		ttl:       ttl,
+		version:   1,
+		timestamp: timestamp,
+	}
+	if old, exists := server.data[shard-1].get(key); exists {
+		// versions keep increasing even if the old value already expired
+		newEntry.version = old.version + 1
+	}
+
+	if server.persistence != nil {
+		if err := server.persistence.logSet(shard, newEntry); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to log write: %v", err)
 		}
-		server.data[shard-1][request.Key] = entry2
-		heap.Push(&server.heaps[shard-1], entry2)
 	}
 
-	return &proto.SetResponse{}, nil
+	// Replaces the old entry (in both the map and the timing wheel) if there was one
+	server.putEntry(shard, newEntry)
+	server.watches.publish(shard, proto.WatchEvent_SET, key, value, newEntry.version)
+	server.evictIfNeeded(shard, newEntry)
+	return newEntry, nil
+}
+
+func (server *KvServerImpl) CompareAndSet(
+	ctx context.Context,
+	request *proto.CompareAndSetRequest,
+) (*proto.CompareAndSetResponse, error) {
+	if request.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	if request.TtlMs < 0 {
+		return nil, status.Error(codes.InvalidArgument, "TTL must be non-negative")
+	}
+
+	shard, err := server.checkShardAssignment(request.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.execute(ctx, shard, &proto.WriteCommand{
+		Op:              proto.WriteCommand_COMPARE_AND_SET,
+		Key:             []byte(request.Key),
+		Value:           []byte(request.Value),
+		ExpiryMs:        expiryFromTtl(request.TtlMs),
+		Timestamp:       server.commandTimestamp(request.Timestamp),
+		ExpectedVersion: request.ExpectedVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.CompareAndSetResponse{Swapped: result.swapped, Version: result.version}, nil
+}
+
+func (server *KvServerImpl) GetTTL(
+	ctx context.Context,
+	request *proto.GetTTLRequest,
+) (*proto.GetTTLResponse, error) {
+	if request.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	shard, err := server.checkShardAssignment(request.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.checkReadable(ctx, shard); err != nil {
+		return nil, err
+	}
+
+	server.locks[shard-1].RLock()
+	defer server.locks[shard-1].RUnlock()
+
+	now := uint64(time.Now().UnixMilli())
+	e, exists := server.data[shard-1].get(request.Key)
+	if !exists || !e.live(now) {
+		return &proto.GetTTLResponse{WasFound: false}, nil
+	}
+	if e.ttl == noExpiry {
+		return &proto.GetTTLResponse{WasFound: true, NoExpiry: true}, nil
+	}
+	return &proto.GetTTLResponse{WasFound: true, TtlMsRemaining: int64(e.ttl - now)}, nil
+}
+
+func (server *KvServerImpl) Touch(
+	ctx context.Context,
+	request *proto.TouchRequest,
+) (*proto.TouchResponse, error) {
+	if request.TtlMs < 0 {
+		return nil, status.Error(codes.InvalidArgument, "TTL must be non-negative")
+	}
+	wasFound, err := server.setExpiry(ctx, request.Key, expiryFromTtl(request.TtlMs), request.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.TouchResponse{WasFound: wasFound}, nil
+}
+
+func (server *KvServerImpl) Persist(
+	ctx context.Context,
+	request *proto.PersistRequest,
+) (*proto.PersistResponse, error) {
+	wasFound, err := server.setExpiry(ctx, request.Key, noExpiry, request.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.PersistResponse{WasFound: wasFound}, nil
+}
+
+/*
+ * Changes the expiration timestamp of a live key, keeping its value and version.
+ * Returns whether the key was found; expired keys are not revived.
+ */
+func (server *KvServerImpl) setExpiry(ctx context.Context, key string, ttl uint64, timestamp uint64) (bool, error) {
+	if key == "" {
+		return false, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	shard, err := server.checkShardAssignment(key)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := server.execute(ctx, shard, &proto.WriteCommand{
+		Op:        proto.WriteCommand_SET_EXPIRY,
+		Key:       []byte(key),
+		ExpiryMs:  ttl,
+		Timestamp: server.commandTimestamp(timestamp),
+	})
+	return result.wasFound, err
+}
+
+func (server *KvServerImpl) Increment(
+	ctx context.Context,
+	request *proto.IncrementRequest,
+) (*proto.IncrementResponse, error) {
+	value, version, err := server.addToCounter(ctx, proto.WriteCommand_INCREMENT, request.Key, request.Delta, request.TtlMs, request.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.IncrementResponse{Value: value, Version: version}, nil
+}
+
+func (server *KvServerImpl) Decrement(
+	ctx context.Context,
+	request *proto.DecrementRequest,
+) (*proto.DecrementResponse, error) {
+	value, version, err := server.addToCounter(ctx, proto.WriteCommand_DECREMENT, request.Key, request.Delta, request.TtlMs, request.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DecrementResponse{Value: value, Version: version}, nil
+}
+
+/*
+ * Shared by Increment and Decrement (see addToCounterEntry): the command is
+ * applied under the shard's write lock so concurrent calls cannot lose updates.
+ * Returns the new value and version.
+ */
+func (server *KvServerImpl) addToCounter(ctx context.Context, op proto.WriteCommand_Op, key string, delta int64, ttlMs int64, timestamp uint64) (int64, uint64, error) {
+	if key == "" {
+		return 0, 0, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	if ttlMs < 0 {
+		return 0, 0, status.Error(codes.InvalidArgument, "TTL must be non-negative")
+	}
+
+	shard, err := server.checkShardAssignment(key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// counters created without a ttl never expire
+	expiry := uint64(noExpiry)
+	if ttlMs > 0 {
+		expiry = expiryFromTtl(ttlMs)
+	}
+	result, err := server.execute(ctx, shard, &proto.WriteCommand{
+		Op:        op,
+		Key:       []byte(key),
+		ExpiryMs:  expiry,
+		Timestamp: server.commandTimestamp(timestamp),
+		Delta:     delta,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.counter, result.version, nil
 }
 
 func (server *KvServerImpl) Delete(
@@ -387,20 +1305,18 @@ func (server *KvServerImpl) Delete(
 	//
 	// panic("TODO: Part A")
 
-	shard, err := server.checkShardAssignment(request.Key)
+	shard, err := server.checkShardWritable(request.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	server.locks[shard-1].Lock()
-	defer server.locks[shard-1].Unlock()
-
-	entry, exists := server.data[shard-1][request.Key]
-	if exists {
-		// Remove entry from heap
-		heap.Remove(&server.heaps[shard-1], entry.index)
-		// Remove from map
-		delete(server.data[shard-1], request.Key)
+	_, err = server.execute(ctx, shard, &proto.WriteCommand{
+		Op:        proto.WriteCommand_DELETE,
+		Key:       []byte(request.Key),
+		Timestamp: server.commandTimestamp(request.Timestamp),
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// delete(server.data[shard-1], request.Key)
@@ -408,6 +1324,106 @@ func (server *KvServerImpl) Delete(
 	return &proto.DeleteResponse{}, nil
 }
 
+/*
+ * Replaces the value of `key` with a tombstone written at `timestamp`, which is
+ * kept for the grace period from `now` (in unix ms).
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) deleteEntry(shard int, key string, timestamp uint64, now uint64) error {
+	tombstone := &entry{key: key, ttl: now + uint64(server.tombstoneGrace.Milliseconds()), timestamp: timestamp, deleted: true}
+	old, exists := server.data[shard-1].get(key)
+	if exists {
+		// versions keep increasing across deletes, like across expiry
+		tombstone.version = old.version
+	}
+	if server.persistence != nil {
+		if err := server.persistence.logSet(shard, tombstone); err != nil {
+			return status.Errorf(codes.Internal, "failed to log delete: %v", err)
+		}
+	}
+	server.putEntry(shard, tombstone)
+	if exists && !old.deleted {
+		server.watches.publish(shard, proto.WatchEvent_DELETE, key, "", 0)
+	}
+	return nil
+}
+
+/*
+ * Removes `key` outright, leaving no tombstone.
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) dropKey(shard int, key string) error {
+	e, exists := server.data[shard-1].get(key)
+	if !exists {
+		return nil
+	}
+	if server.persistence != nil {
+		if err := server.persistence.logDelete(shard, key); err != nil {
+			return status.Errorf(codes.Internal, "failed to log delete: %v", err)
+		}
+	}
+	server.removeEntry(shard, e)
+	if !e.deleted {
+		server.watches.publish(shard, proto.WatchEvent_DELETE, key, "", 0)
+	}
+	return nil
+}
+
+/*
+ * Batched Get/Set/Delete: each key is handled exactly as the single-key RPC
+ * would (taking and releasing its shard's lock), and failures are reported per
+ * key rather than failing the whole batch.
+ */
+func (server *KvServerImpl) MultiGet(
+	ctx context.Context,
+	request *proto.MultiGetRequest,
+) (*proto.MultiGetResponse, error) {
+	values := make([]*proto.MultiGetValue, len(request.Keys))
+	for i, key := range request.Keys {
+		response, err := server.Get(ctx, &proto.GetRequest{Key: key})
+		values[i] = &proto.MultiGetValue{Key: key, Status: keyStatus(key, err)}
+		if err == nil {
+			values[i].Value = response.Value
+			values[i].WasFound = response.WasFound
+			if response.WasFound {
+				values[i].Version = response.Version
+			}
+		}
+	}
+	return &proto.MultiGetResponse{Values: values}, nil
+}
+
+func (server *KvServerImpl) MultiSet(
+	ctx context.Context,
+	request *proto.MultiSetRequest,
+) (*proto.MultiSetResponse, error) {
+	statuses := make([]*proto.KeyStatus, len(request.Entries))
+	for i, entry := range request.Entries {
+		_, err := server.Set(ctx, entry)
+		statuses[i] = keyStatus(entry.Key, err)
+	}
+	return &proto.MultiSetResponse{Statuses: statuses}, nil
+}
+
+func (server *KvServerImpl) MultiDelete(
+	ctx context.Context,
+	request *proto.MultiDeleteRequest,
+) (*proto.MultiDeleteResponse, error) {
+	statuses := make([]*proto.KeyStatus, len(request.Keys))
+	for i, key := range request.Keys {
+		_, err := server.Delete(ctx, &proto.DeleteRequest{Key: key, Timestamp: request.Timestamp})
+		statuses[i] = keyStatus(key, err)
+	}
+	return &proto.MultiDeleteResponse{Statuses: statuses}, nil
+}
+
+func keyStatus(key string, err error) *proto.KeyStatus {
+	s := status.Convert(err)
+	return &proto.KeyStatus{Key: key, Code: uint32(s.Code()), Message: s.Message()}
+}
+
 func (server *KvServerImpl) GetShardContents(
 	ctx context.Context,
 	request *proto.GetShardContentsRequest,
@@ -418,11 +1434,254 @@ func (server *KvServerImpl) GetShardContents(
 	if !server.isShardHosted(shard) {
 		return nil, status.Error(codes.NotFound, "Shard not hosted on this server")
 	}
+	snapshot, err := server.snapshotShard(shard)
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]*proto.GetShardValue, 0, snapshot.size())
+	now := uint64(time.Now().UnixMilli())
+	snapshot.iterate(func(v *entry) bool {
+		kvs = append(kvs, shardValue(v, now))
+		return true
+	})
+	return &proto.GetShardContentsResponse{Values: kvs}, nil
+}
+
+/*
+ * A point-in-time view of `shard`, which can be read without holding the shard
+ * lock (so writers are only blocked while it is taken, see ShardStore.snapshot).
+ * Fails once the server shut down and its data was wiped.
+ */
+func (server *KvServerImpl) snapshotShard(shard int) (shardSnapshot, error) {
+	server.shardLock.RLock()
+	defer server.shardLock.RUnlock()
+	if server.data == nil {
+		return nil, status.Error(codes.Unavailable, "Server shutting down")
+	}
+	server.locks[shard-1].Lock()
+	defer server.locks[shard-1].Unlock()
+	if server.data[shard-1] == nil {
+		return entryList(nil), nil
+	}
+	return server.data[shard-1].snapshot(), nil
+}
+
+/*
+ * Streams the contents of a shard in chunks of at most MaxChunkBytes (plus at most
+ * one entry). Like GetShardContents, this sends a snapshot of the shard as of the
+ * start of the call, so writers are not stalled by the transfer; keys written after
+ * it starts are not included. Keys which expire before their chunk is built are
+ * skipped.
+ */
+func (server *KvServerImpl) StreamShardContents(
+	request *proto.StreamShardContentsRequest,
+	stream proto.Kv_StreamShardContentsServer,
+) error {
+	shard := int(request.Shard)
+	if !server.isShardHosted(shard) {
+		return status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	maxChunkBytes := int(request.MaxChunkBytes)
+	if maxChunkBytes <= 0 {
+		maxChunkBytes = shardCopyChunkBytes
+	}
+
+	snapshot, err := server.snapshotShard(shard)
+	if err != nil {
+		return err
+	}
+	chunk := make([]*proto.GetShardValue, 0)
+	chunkBytes := 0
+	sent := 0
+	now := uint64(time.Now().UnixMilli())
+	snapshot.iterate(func(e *entry) bool {
+		if e.ttl < now {
+			return true
+		}
+		value := shardValue(e, now)
+		chunk = append(chunk, value)
+		chunkBytes += gproto.Size(value)
+		if chunkBytes < maxChunkBytes {
+			return true
+		}
+		err = stream.Send(&proto.GetShardContentsResponse{Values: chunk})
+		chunk, chunkBytes = make([]*proto.GetShardValue, 0), 0
+		sent++
+		now = uint64(time.Now().UnixMilli())
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(chunk) > 0 || sent == 0 {
+		// the rest, or an empty chunk so the receiver knows the shard is empty
+		return stream.Send(&proto.GetShardContentsResponse{Values: chunk})
+	}
+	return nil
+}
+
+/*
+ * Returns one page of the keys in a shard matching the request's prefix, in
+ * ascending order, starting after request.StartAfter. Expired entries and
+ * tombstones are skipped.
+ */
+func (server *KvServerImpl) Scan(
+	ctx context.Context,
+	request *proto.ScanRequest,
+) (*proto.ScanResponse, error) {
+	shard := int(request.Shard)
+	if !server.isShardHosted(shard) {
+		return nil, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = defaultScanLimit
+	} else if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+
+	server.shardLock.RLock()
+	defer server.shardLock.RUnlock()
+	if server.data == nil {
+		return nil, status.Error(codes.Unavailable, "Server shutting down")
+	}
 	server.locks[shard-1].RLock()
 	defer server.locks[shard-1].RUnlock()
-	kvs := make([]*proto.GetShardValue, 0)
-	for k, v := range server.data[shard-1] {
-		kvs = append(kvs, &proto.GetShardValue{Key: k, Value: v.value, TtlMsRemaining: int64(v.ttl) - int64(time.Now().UnixMilli())})
+
+	now := uint64(time.Now().UnixMilli())
+	prefix, startAfter := string(request.Prefix), string(request.StartAfter)
+	matches := make([]*entry, 0)
+	if ordered, ok := server.data[shard-1].(orderedShardStore); ok {
+		// keys come in order, so stop at the first one past the prefix, or once we
+		// know whether there is more than a page
+		ordered.ascend(max(prefix, startAfter), func(e *entry) bool {
+			if !strings.HasPrefix(e.key, prefix) {
+				return false
+			}
+			if e.key > startAfter && e.live(now) {
+				matches = append(matches, e)
+			}
+			return len(matches) <= limit
+		})
+	} else {
+		server.data[shard-1].iterate(func(e *entry) bool {
+			if e.key > startAfter && strings.HasPrefix(e.key, prefix) && e.live(now) {
+				matches = append(matches, e)
+			}
+			return true
+		})
+		sort.Slice(matches, func(i, j int) bool {
+			return matches[i].key < matches[j].key
+		})
 	}
-	return &proto.GetShardContentsResponse{Values: kvs}, nil
+
+	more := len(matches) > limit
+	if more {
+		matches = matches[:limit]
+	}
+	values := make([]*proto.GetShardValue, len(matches))
+	for i, e := range matches {
+		values[i] = shardValue(e, now)
+	}
+	return &proto.ScanResponse{Values: values, More: more}, nil
+}
+
+/*
+ * Stores a value read from another replica, e.g. by a client repairing this one
+ * after finding it stale. The value keeps its version, expiry and timestamp, and
+ * is only applied if it is newer (see newerValue) than the live value we have:
+ * a write which reached us since the client read must not be undone.
+ */
+func (server *KvServerImpl) ReplicaWrite(
+	ctx context.Context,
+	request *proto.ReplicaWriteRequest,
+) (*proto.ReplicaWriteResponse, error) {
+	if err := server.rejectInRaftMode(); err != nil {
+		return nil, err
+	}
+	value := request.Value
+	if value == nil || len(value.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty key not allowed")
+	}
+	shard, err := server.checkShardWritable(string(value.Key))
+	if err != nil {
+		return nil, err
+	}
+	applied, err := server.storeReplicaValue(shard, value)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReplicaWriteResponse{Applied: applied}, nil
+}
+
+/*
+ * Stores a value (or tombstone) from another replica unless our value or
+ * tombstone is at least as new. Shared by ReplicaWrite and anti-entropy.
+ * Returns whether it was stored.
+ */
+func (server *KvServerImpl) storeReplicaValue(shard int, value *proto.GetShardValue) (bool, error) {
+	now := uint64(time.Now().UnixMilli())
+	if !value.NoExpiry && value.TtlMsRemaining <= 0 {
+		return false, nil
+	}
+
+	server.shardLock.RLock()
+	defer server.shardLock.RUnlock()
+	if server.data == nil {
+		return false, status.Error(codes.Unavailable, "Server shutting down")
+	}
+	server.locks[shard-1].Lock()
+	defer server.locks[shard-1].Unlock()
+
+	if server.data[shard-1] == nil {
+		return false, status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	current, exists := server.data[shard-1].get(string(value.Key))
+	if exists && current.ttl >= now && !newerValue(value.Timestamp, value.Version, current.timestamp, current.version) {
+		return false, nil
+	}
+	if err := server.storeEntry(shard, entryFromShardValue(value, now)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+/*
+ * Stores an entry copied from another replica as is (keeping its version).
+ *
+ * NOTE: must hold the (write) lock for the shard
+ */
+func (server *KvServerImpl) storeEntry(shard int, newEntry *entry) error {
+	if server.persistence != nil {
+		if err := server.persistence.logSet(shard, newEntry); err != nil {
+			return status.Errorf(codes.Internal, "failed to log write: %v", err)
+		}
+	}
+	old, exists := server.data[shard-1].get(newEntry.key)
+	server.putEntry(shard, newEntry)
+	if !newEntry.deleted {
+		server.watches.publish(shard, proto.WatchEvent_SET, newEntry.key, newEntry.value, newEntry.version)
+	} else if exists && !old.deleted {
+		server.watches.publish(shard, proto.WatchEvent_DELETE, newEntry.key, "", 0)
+	}
+	server.evictIfNeeded(shard, newEntry)
+	return nil
+}
+
+/*
+ * Counters for this node (see Stats) plus a few gauges computed on the fly.
+ */
+func (server *KvServerImpl) Stats() map[string]int64 {
+	stats := server.stats.Snapshot()
+	stats["memory_bytes"] = server.memoryBytes.Load()
+	stats["memory_budget_bytes"] = server.memoryBudget
+	stats["hints_pending"] = int64(server.hints.size())
+	return stats
+}
+
+func (server *KvServerImpl) GetStats(
+	ctx context.Context,
+	request *proto.GetStatsRequest,
+) (*proto.GetStatsResponse, error) {
+	return &proto.GetStatsResponse{Counters: server.Stats()}, nil
 }
@@ -0,0 +1,43 @@
+package kv
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+/*
+ * Stats is a set of named counters (evictions, repairs, ...) which can be
+ * incremented from any goroutine without taking a lock on the hot path.
+ * Servers expose theirs through the GetStats RPC.
+ */
+type Stats struct {
+	counters sync.Map // string -> *atomic.Int64
+}
+
+func (s *Stats) Add(name string, delta int64) {
+	counter, ok := s.counters.Load(name)
+	if !ok {
+		counter, _ = s.counters.LoadOrStore(name, new(atomic.Int64))
+	}
+	counter.(*atomic.Int64).Add(delta)
+}
+
+func (s *Stats) Get(name string) int64 {
+	counter, ok := s.counters.Load(name)
+	if !ok {
+		return 0
+	}
+	return counter.(*atomic.Int64).Load()
+}
+
+/*
+ * Returns a copy of all counters. Counters which were never incremented are absent.
+ */
+func (s *Stats) Snapshot() map[string]int64 {
+	snapshot := make(map[string]int64)
+	s.counters.Range(func(name, counter any) bool {
+		snapshot[name.(string)] = counter.(*atomic.Int64).Load()
+		return true
+	})
+	return snapshot
+}
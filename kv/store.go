@@ -0,0 +1,238 @@
+package kv
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+ * Storage engine holding the entries of one shard, along with the index of when
+ * they expire. The server keeps one per shard it has data for, and calls it while
+ * holding the lock for the shard: the write lock for set/delete/expire/clear/
+ * snapshot, at least the read lock for the rest. Engines need no locking of their
+ * own.
+ *
+ * Stores only hold entries: bookkeeping such as memory accounting, eviction, the
+ * WAL and watches stays in the server, so every engine gets it for free.
+ */
+type ShardStore interface {
+	// The entry for key, whether or not it has expired
+	get(key string) (*entry, bool)
+	// Adds e, which must not share its key with a stored entry (delete that first)
+	set(e *entry)
+	// Removes the entry for key, returning it
+	delete(key string) (*entry, bool)
+	// Calls fn on every entry (expired or not) until it returns false. fn must not
+	// modify the store.
+	iterate(fn func(e *entry) bool)
+	// Removes and returns the entries which expired before `now` (see timingWheel.advance)
+	expire(now uint64) []*entry
+	// The value which expires first, or nil if the store holds none (see timingWheel.soonest)
+	soonest() *entry
+	// Number of entries stored
+	size() int
+	// Removes every entry
+	clear()
+	// A point-in-time view of the entries, which stays valid (and unchanged) after
+	// the shard lock is released. Stored entries are never modified, so snapshots
+	// can share them.
+	snapshot() shardSnapshot
+}
+
+/*
+ * Read-only view of a shard, for work which should not hold the shard lock for as
+ * long as it takes, such as shard copies and checkpoints. Safe to use from any
+ * goroutine without locking.
+ */
+type shardSnapshot interface {
+	// Calls fn on every entry (expired or not) until it returns false
+	iterate(fn func(e *entry) bool)
+	size() int
+}
+
+// Snapshot of a store which cannot share its structure: a copy of the entry list
+type entryList []*entry
+
+func (l entryList) iterate(fn func(e *entry) bool) {
+	for _, e := range l {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+func (l entryList) size() int {
+	return len(l)
+}
+
+/*
+ * Implemented by engines which keep keys sorted, so Scan need not sort a shard.
+ */
+type orderedShardStore interface {
+	ShardStore
+	// Like iterate, but in ascending key order, starting at the first key >= from
+	ascend(from string, fn func(e *entry) bool)
+}
+
+const (
+	StorageMap   = "map"
+	StorageBTree = "btree"
+)
+
+// Every engine, the default first
+var StorageEngines = []string{StorageMap, StorageBTree}
+
+// Checks that an engine exists, and returns a function creating empty stores for it
+func shardStoreFactory(name string) (func() ShardStore, error) {
+	tickMs := uint64(expiryTick.Milliseconds())
+	switch name {
+	case StorageMap, "":
+		return func() ShardStore {
+			return &mapStore{entries: make(map[string]*entry), wheel: newTimingWheel(tickMs, uint64(time.Now().UnixMilli()))}
+		}, nil
+	case StorageBTree:
+		return func() ShardStore {
+			return &btreeStore{wheel: newTimingWheel(tickMs, uint64(time.Now().UnixMilli()))}
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage engine: %q", name)
+}
+
+/*
+ * The default engine: a hash map, with expiry tracked in a timing wheel.
+ */
+type mapStore struct {
+	entries map[string]*entry
+	wheel   *timingWheel
+}
+
+func (s *mapStore) get(key string) (*entry, bool) {
+	e, exists := s.entries[key]
+	return e, exists
+}
+
+func (s *mapStore) set(e *entry) {
+	s.entries[e.key] = e
+	s.wheel.add(e)
+}
+
+func (s *mapStore) delete(key string) (*entry, bool) {
+	e, exists := s.entries[key]
+	if exists {
+		delete(s.entries, key)
+		s.wheel.remove(e)
+	}
+	return e, exists
+}
+
+func (s *mapStore) iterate(fn func(e *entry) bool) {
+	for _, e := range s.entries {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+func (s *mapStore) expire(now uint64) []*entry {
+	expired := s.wheel.advance(now)
+	for _, e := range expired {
+		delete(s.entries, e.key)
+	}
+	return expired
+}
+
+func (s *mapStore) soonest() *entry {
+	return s.wheel.soonest()
+}
+
+func (s *mapStore) size() int {
+	return len(s.entries)
+}
+
+func (s *mapStore) clear() {
+	s.entries = make(map[string]*entry)
+	s.wheel.reset(uint64(time.Now().UnixMilli()))
+}
+
+// Copies the entry pointers: O(n), but far cheaper than anything done per entry
+// with the snapshot, so the shard lock is only held briefly
+func (s *mapStore) snapshot() shardSnapshot {
+	list := make(entryList, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, e)
+	}
+	return list
+}
+
+/*
+ * Keeps entries sorted by key in a B-tree (see btree.go), which makes Scan cheap
+ * at the cost of O(log n) lookups, and snapshots O(1) since the tree is
+ * copy-on-write. Expiry is tracked in a timing wheel.
+ */
+type btreeStore struct {
+	tree  btree
+	wheel *timingWheel
+}
+
+func (s *btreeStore) get(key string) (*entry, bool) {
+	e := s.tree.get(key)
+	return e, e != nil
+}
+
+func (s *btreeStore) set(e *entry) {
+	s.tree.insert(e)
+	s.wheel.add(e)
+}
+
+func (s *btreeStore) delete(key string) (*entry, bool) {
+	e := s.tree.remove(key)
+	if e != nil {
+		s.wheel.remove(e)
+	}
+	return e, e != nil
+}
+
+func (s *btreeStore) iterate(fn func(e *entry) bool) {
+	s.tree.ascend("", fn)
+}
+
+func (s *btreeStore) ascend(from string, fn func(e *entry) bool) {
+	s.tree.ascend(from, fn)
+}
+
+func (s *btreeStore) expire(now uint64) []*entry {
+	expired := s.wheel.advance(now)
+	for _, e := range expired {
+		s.tree.remove(e.key)
+	}
+	return expired
+}
+
+func (s *btreeStore) soonest() *entry {
+	return s.wheel.soonest()
+}
+
+func (s *btreeStore) size() int {
+	return s.tree.length
+}
+
+func (s *btreeStore) clear() {
+	s.tree = btree{}
+	s.wheel.reset(uint64(time.Now().UnixMilli()))
+}
+
+func (s *btreeStore) snapshot() shardSnapshot {
+	return btreeSnapshot{s.tree.snapshot()}
+}
+
+type btreeSnapshot struct {
+	tree *btree
+}
+
+func (s btreeSnapshot) iterate(fn func(e *entry) bool) {
+	s.tree.ascend("", fn)
+}
+
+func (s btreeSnapshot) size() int {
+	return s.tree.length
+}
@@ -0,0 +1,171 @@
+package kvtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for anti-entropy between replicas of a shard.
+
+func makeAntiEntropySetup(shardMap kv.ShardMapState, keysPerSecond int) *TestSetup {
+	return MakeTestSetupWithOptions(shardMap, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{
+			AntiEntropyInterval:      100 * time.Millisecond,
+			AntiEntropyKeysPerSecond: keysPerSecond,
+		}
+	})
+}
+
+func merkleRoot(t *testing.T, setup *TestSetup, node string) uint64 {
+	response, err := setup.nodes[node].GetMerkleHashes(setup.ctx, &proto.MerkleHashesRequest{Shard: 1, Nodes: []uint32{1}})
+	assert.Nil(t, err)
+	return response.Hashes[0]
+}
+
+func TestAntiEntropyRepairsDivergedReplicas(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeTwoNodeBothAssignedSingleShard(), 0)
+
+	assert.Nil(t, setup.NodeSet("n1", "only-n1", "a", 10*time.Second))
+	assert.Nil(t, setup.NodeSet("n2", "only-n2", "b", 10*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "conflict", "older", 10*time.Second))
+	time.Sleep(time.Millisecond)
+	assert.Nil(t, setup.NodeSet("n2", "conflict", "newer", 10*time.Second))
+
+	expected := map[string]string{"only-n1": "a", "only-n2": "b", "conflict": "newer"}
+	assert.Eventually(t, func() bool {
+		for _, node := range []string{"n1", "n2"} {
+			for key, value := range expected {
+				val, wasFound, err := setup.NodeGet(node, key)
+				if err != nil || !wasFound || val != value {
+					return false
+				}
+			}
+		}
+		return true
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, merkleRoot(t, setup, "n1"), merkleRoot(t, setup, "n2"))
+	assert.Greater(t, totalStat(setup, "anti_entropy_shards_differed"), int64(0))
+	assert.Greater(t, totalStat(setup, "anti_entropy_keys_pulled")+totalStat(setup, "anti_entropy_keys_pushed"), int64(2))
+
+	setup.Shutdown()
+}
+
+func TestAntiEntropyInSyncReplicas(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeThreeNodesAllAssignedSingleShard(), 0)
+	for i := 0; i < 50; i++ {
+		assert.Nil(t, setup.Set(fmt.Sprintf("key-%d", i), "value", 10*time.Second))
+	}
+
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "anti_entropy_shards_compared") >= 6
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, int64(0), totalStat(setup, "anti_entropy_shards_differed"))
+	assert.Equal(t, int64(0), totalStat(setup, "anti_entropy_keys_pulled"))
+	assert.Equal(t, int64(0), totalStat(setup, "anti_entropy_keys_pushed"))
+
+	setup.Shutdown()
+}
+
+func TestAntiEntropyRateLimited(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeTwoNodeBothAssignedSingleShard(), 20)
+	keys := RandomKeys(100, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.NodeSet("n1", key, "value", 10*time.Second))
+	}
+
+	missing := func() int {
+		count := 0
+		for _, key := range keys {
+			if _, wasFound, _ := setup.NodeGet("n2", key); !wasFound {
+				count++
+			}
+		}
+		return count
+	}
+	// each node syncs a burst of 20 keys, then 20 per second
+	time.Sleep(500 * time.Millisecond)
+	assert.Greater(t, missing(), 30)
+	assert.Eventually(t, func() bool {
+		return missing() == 0
+	}, 8*time.Second, 50*time.Millisecond)
+
+	setup.Shutdown()
+}
+
+func TestAntiEntropySkipsUnreachablePeer(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeTwoNodeBothAssignedSingleShard(), 0)
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 10*time.Second))
+
+	assert.Eventually(t, func() bool {
+		return setup.nodes["n1"].Stats()["anti_entropy_errors"] > 0
+	}, 2*time.Second, 20*time.Millisecond)
+
+	setup.clientPool.ClearRpcOverrides("n2")
+	assert.Eventually(t, func() bool {
+		val, wasFound, err := setup.NodeGet("n2", "abc")
+		return err == nil && wasFound && val == "123"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	setup.Shutdown()
+}
+
+func TestAntiEntropyRepairsMissedExpiryChange(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeTwoNodeBothAssignedSingleShard(), 0)
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	_, err := setup.kv.Persist(setup.ctx, "abc")
+	assert.NotNil(t, err)
+	setup.clientPool.ClearRpcOverrides("n2")
+
+	assert.Eventually(t, func() bool {
+		return nodeGetTTL(t, setup, "n2", "abc").NoExpiry
+	}, 2*time.Second, 20*time.Millisecond)
+
+	setup.Shutdown()
+}
+
+func TestServerMerkleHashesCached(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 10*time.Second))
+
+	// each level of a comparison reads the same tree
+	root := merkleRoot(t, setup, "n1")
+	assert.Equal(t, root, merkleRoot(t, setup, "n1"))
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["merkle_tree_cache_hits"])
+
+	// until the shard changes
+	assert.Nil(t, setup.NodeSet("n1", "abc", "456", 10*time.Second))
+	assert.NotEqual(t, root, merkleRoot(t, setup, "n1"))
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["merkle_tree_cache_hits"])
+
+	setup.Shutdown()
+}
+
+func TestServerMerkleHashes(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+	assert.Equal(t, merkleRoot(t, setup, "n1"), merkleRoot(t, setup, "n2"))
+
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	assert.Equal(t, merkleRoot(t, setup, "n1"), merkleRoot(t, setup, "n2"))
+	assert.NotEqual(t, uint64(0), merkleRoot(t, setup, "n1"))
+
+	assert.Nil(t, setup.NodeSet("n2", "abc", "456", 10*time.Second))
+	assert.NotEqual(t, merkleRoot(t, setup, "n1"), merkleRoot(t, setup, "n2"))
+
+	_, err := setup.nodes["n1"].GetMerkleHashes(setup.ctx, &proto.MerkleHashesRequest{Shard: 1, Nodes: []uint32{0}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = setup.nodes["n1"].GetMerkleHashes(setup.ctx, &proto.MerkleHashesRequest{Shard: 2, Nodes: []uint32{1}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = setup.nodes["n1"].GetMerkleLeaves(setup.ctx, &proto.MerkleLeavesRequest{Shard: 1, Leaves: []uint32{1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	setup.Shutdown()
+}
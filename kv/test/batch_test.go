@@ -0,0 +1,151 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for the batched MultiGet/MultiSet/MultiDelete calls.
+
+func TestServerMultiOps(t *testing.T) {
+	setup := MakeTestSetup(MakeSingleNodeHalfShardsAssigned())
+	server := setup.nodes["n1"]
+
+	// "abc" is on shard 4 (hosted), "xyz" on shard 7 (not hosted)
+	setResponse, err := server.MultiSet(context.Background(), &proto.MultiSetRequest{
+		Entries: []*proto.SetRequest{
+			{Key: "abc", Value: "123", TtlMs: 10000},
+			{Key: "xyz", Value: "456", TtlMs: 10000},
+			{Key: "", Value: "x", TtlMs: 10000},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(setResponse.Statuses))
+	assert.Equal(t, uint32(codes.OK), setResponse.Statuses[0].Code)
+	assert.Equal(t, uint32(codes.NotFound), setResponse.Statuses[1].Code)
+	assert.Equal(t, uint32(codes.InvalidArgument), setResponse.Statuses[2].Code)
+
+	getResponse, err := server.MultiGet(context.Background(), &proto.MultiGetRequest{Keys: []string{"abc", "xyz", "def"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(getResponse.Values))
+	assert.Equal(t, "abc", getResponse.Values[0].Key)
+	assert.True(t, getResponse.Values[0].WasFound)
+	assert.Equal(t, "123", getResponse.Values[0].Value)
+	assert.Equal(t, uint32(codes.NotFound), getResponse.Values[1].Status.Code)
+	assert.Equal(t, uint32(codes.OK), getResponse.Values[2].Status.Code)
+	assert.False(t, getResponse.Values[2].WasFound)
+
+	deleteResponse, err := server.MultiDelete(context.Background(), &proto.MultiDeleteRequest{Keys: []string{"abc", "xyz"}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(codes.OK), deleteResponse.Statuses[0].Code)
+	assert.Equal(t, uint32(codes.NotFound), deleteResponse.Statuses[1].Code)
+
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestClientMultiOpsAcrossShards(t *testing.T) {
+	setup := MakeTestSetup(MakeFourNodesWithFiveShards())
+
+	keys := RandomKeys(200, 10)
+	values := make(map[string]string)
+	for _, key := range keys {
+		values[key] = key + "-value"
+	}
+	errs := setup.kv.MultiSet(setup.ctx, values, 10*time.Second)
+	assert.Empty(t, errs)
+
+	// one batch per node rather than one request per key
+	for _, node := range []string{"n1", "n2", "n3", "n4"} {
+		assert.Equal(t, 1, setup.clientPool.GetRequestsSent(node))
+		setup.clientPool.ClearRequestsSent(node)
+	}
+
+	results := setup.kv.MultiGet(setup.ctx, append(keys, "not-set"))
+	assert.Equal(t, len(keys)+1, len(results))
+	for _, key := range keys {
+		assert.Nil(t, results[key].Err)
+		assert.True(t, results[key].WasFound)
+		assert.Equal(t, key+"-value", results[key].Value)
+	}
+	assert.Nil(t, results["not-set"].Err)
+	assert.False(t, results["not-set"].WasFound)
+	sent := 0
+	for _, node := range []string{"n1", "n2", "n3", "n4"} {
+		assert.LessOrEqual(t, setup.clientPool.GetRequestsSent(node), 1)
+		sent += setup.clientPool.GetRequestsSent(node)
+	}
+	assert.LessOrEqual(t, sent, 4)
+
+	errs = setup.kv.MultiDelete(setup.ctx, keys[:100])
+	assert.Empty(t, errs)
+	results = setup.kv.MultiGet(setup.ctx, keys)
+	for i, key := range keys {
+		assert.Nil(t, results[key].Err)
+		assert.Equal(t, i >= 100, results[key].WasFound)
+	}
+
+	setup.Shutdown()
+}
+
+func TestClientMultiGetFailsOver(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	keys := RandomKeys(20, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set(key, "value", 10*time.Second))
+	}
+
+	setup.clientPool.OverrideRpcError("n1", errors.New("oh no!"))
+	for i := 0; i < 2; i++ {
+		results := setup.kv.MultiGet(setup.ctx, keys)
+		for _, key := range keys {
+			assert.Nil(t, results[key].Err)
+			assert.Equal(t, "value", results[key].Value)
+		}
+	}
+
+	setup.clientPool.OverrideRpcError("n2", errors.New("oh no!"))
+	results := setup.kv.MultiGet(setup.ctx, keys)
+	for _, key := range keys {
+		assert.NotNil(t, results[key].Err)
+	}
+
+	setup.Shutdown()
+}
+
+func TestClientMultiSetPerKeyErrors(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeMultiShard())
+
+	// empty keys are rejected individually
+	errs := setup.kv.MultiSet(setup.ctx, map[string]string{"": "x", "abc": "123"}, 10*time.Second)
+	assert.Equal(t, 1, len(errs))
+	assertErrorWithCode(t, errs[""], codes.InvalidArgument)
+
+	// n2 is down: keys on shards 6-10 fail, the rest are still written
+	setup.clientPool.OverrideRpcError("n2", errors.New("oh no!"))
+	keys := RandomKeys(100, 10)
+	values := make(map[string]string)
+	for _, key := range keys {
+		values[key] = "value"
+	}
+	errs = setup.kv.MultiSet(setup.ctx, values, 10*time.Second)
+	assert.NotEmpty(t, errs)
+	for _, key := range keys {
+		shard := kv.GetShardForKey(key, setup.NumShards())
+		_, failed := errs[key]
+		assert.Equal(t, shard > 5, failed)
+	}
+
+	setup.Shutdown()
+}
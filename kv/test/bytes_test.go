@@ -0,0 +1,143 @@
+package kvtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// Tests for binary keys and values (GetBytes/SetBytes/DeleteBytes).
+
+var binaryKey = []byte{0x00, 0xff, 'k', 0xc3}
+var binaryValue = []byte{0xde, 0xad, 0x00, 0xbe, 0xef, 0xff, 0xfe}
+
+func TestServerBytesRoundTrip(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	server := setup.nodes["n1"]
+	ctx := context.Background()
+
+	_, err := server.SetBytes(ctx, &proto.SetBytesRequest{Key: binaryKey, Value: binaryValue, TtlMs: 10000})
+	assert.Nil(t, err)
+
+	response, err := server.GetBytes(ctx, &proto.GetBytesRequest{Key: binaryKey})
+	assert.Nil(t, err)
+	assert.True(t, response.WasFound)
+	assert.Equal(t, binaryValue, response.Value)
+	assert.Equal(t, uint64(1), response.Version)
+
+	// the string API refuses to return a value it could not encode
+	assert.Nil(t, setup.NodeSet("n1", "text-key", string(binaryValue), 10*time.Second))
+	_, _, err = setup.NodeGet("n1", "text-key")
+	assertErrorWithCode(t, err, codes.FailedPrecondition)
+
+	// but both APIs see the same data
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 10*time.Second))
+	response, err = server.GetBytes(ctx, &proto.GetBytesRequest{Key: []byte("abc")})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("123"), response.Value)
+
+	_, err = server.DeleteBytes(ctx, &proto.DeleteBytesRequest{Key: binaryKey})
+	assert.Nil(t, err)
+	response, err = server.GetBytes(ctx, &proto.GetBytesRequest{Key: binaryKey})
+	assert.Nil(t, err)
+	assert.False(t, response.WasFound)
+
+	_, err = server.GetBytes(ctx, &proto.GetBytesRequest{})
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+	_, err = server.SetBytes(ctx, &proto.SetBytesRequest{Value: binaryValue, TtlMs: 10000})
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+
+	setup.Shutdown()
+}
+
+func TestBinaryShardContentsMarshal(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	_, err := setup.nodes["n1"].SetBytes(context.Background(), &proto.SetBytesRequest{Key: binaryKey, Value: binaryValue, TtlMs: 10000})
+	assert.Nil(t, err)
+
+	// shard transfers must survive a real encode/decode, which rejects invalid UTF-8 strings
+	response, err := setup.nodes["n1"].GetShardContents(context.Background(), &proto.GetShardContentsRequest{Shard: 1})
+	assert.Nil(t, err)
+	encoded, err := gproto.Marshal(response)
+	assert.Nil(t, err)
+	decoded := &proto.GetShardContentsResponse{}
+	assert.Nil(t, gproto.Unmarshal(encoded, decoded))
+	assert.Equal(t, 1, len(decoded.Values))
+	assert.Equal(t, binaryKey, decoded.Values[0].Key)
+	assert.Equal(t, binaryValue, decoded.Values[0].Value)
+
+	chunks, err := streamShard(t, setup.nodes["n1"], 1, 0)
+	assert.Nil(t, err)
+	for _, chunk := range chunks {
+		_, err := gproto.Marshal(chunk)
+		assert.Nil(t, err)
+	}
+
+	setup.Shutdown()
+}
+
+func TestBinaryValuesSurviveShardMove(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards: 1,
+		Nodes:     makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{
+			1: {"n1"},
+		},
+	})
+
+	assert.Nil(t, setup.kv.SetBytes(setup.ctx, binaryKey, binaryValue, 10*time.Second))
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+
+	response, err := setup.nodes["n2"].GetBytes(context.Background(), &proto.GetBytesRequest{Key: binaryKey})
+	assert.Nil(t, err)
+	assert.True(t, response.WasFound)
+	assert.Equal(t, binaryValue, response.Value)
+
+	setup.Shutdown()
+}
+
+func TestBinaryValuesSurviveRestart(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.kv.SetBytes(setup.ctx, binaryKey, binaryValue, 10*time.Second))
+	assert.Nil(t, setup.RestartNode("n1"))
+
+	value, wasFound, err := setup.kv.GetBytes(setup.ctx, binaryKey)
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, binaryValue, value)
+
+	setup.Shutdown()
+}
+
+func TestClientBytesReplicated(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	assert.Nil(t, setup.kv.SetBytes(setup.ctx, binaryKey, binaryValue, 10*time.Second))
+	for _, node := range []string{"n1", "n2"} {
+		response, err := setup.nodes[node].GetBytes(context.Background(), &proto.GetBytesRequest{Key: binaryKey})
+		assert.Nil(t, err)
+		assert.Equal(t, binaryValue, response.Value)
+	}
+
+	// binary prefixes work for scans too
+	entries, _, err := setup.kv.Scan(setup.ctx, string(binaryKey[:2]), "", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.True(t, bytes.Equal(binaryValue, []byte(entries[0].Value)))
+
+	assert.Nil(t, setup.kv.DeleteBytes(setup.ctx, binaryKey))
+	_, wasFound, err := setup.kv.GetBytes(setup.ctx, binaryKey)
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
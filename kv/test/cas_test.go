@@ -0,0 +1,146 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for per-key versions and CompareAndSet.
+
+func TestServerVersionIncrementsOnWrite(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	_, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+	assert.Equal(t, uint64(0), version)
+
+	for i := 1; i <= 3; i++ {
+		assert.Nil(t, setup.NodeSet("n1", "abc", "123", 10*time.Second))
+		_, version, wasFound, err = setup.NodeGetWithVersion("n1", "abc")
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, uint64(i), version)
+	}
+
+	setup.Shutdown()
+}
+
+func TestServerCompareAndSet(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	// 0 means "must not exist"
+	swapped, version, err := setup.NodeCompareAndSet("n1", "abc", "first", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(1), version)
+
+	swapped, version, err = setup.NodeCompareAndSet("n1", "abc", "second", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, uint64(1), version)
+
+	swapped, version, err = setup.NodeCompareAndSet("n1", "abc", "second", 10*time.Second, 1)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(2), version)
+
+	swapped, version, err = setup.NodeCompareAndSet("n1", "abc", "stale", 10*time.Second, 1)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, uint64(2), version)
+
+	val, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "second", val)
+	assert.Equal(t, uint64(2), version)
+
+	_, _, err = setup.NodeCompareAndSet("n1", "", "x", 10*time.Second, 0)
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+
+	setup.Shutdown()
+}
+
+func TestServerCompareAndSetExpiredKey(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 100*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+
+	// an expired key counts as absent, but its version is not reused
+	swapped, version, err := setup.NodeCompareAndSet("n1", "abc", "456", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(2), version)
+
+	setup.Shutdown()
+}
+
+func TestVersionSurvivesShardMove(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeMultiShard())
+
+	keys := RandomKeys(50, 10)
+	for _, key := range keys {
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, setup.Set(key, "value", 100*time.Second))
+		}
+	}
+	for shard := 1; shard <= 5; shard++ {
+		assert.Nil(t, setup.MoveShard(shard, "n1", "n2"))
+	}
+
+	for _, key := range keys {
+		_, version, wasFound, err := setup.NodeGetWithVersion("n2", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, uint64(3), version)
+	}
+
+	setup.Shutdown()
+}
+
+func TestVersionSurvivesRestart(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "1", 100*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "abc", "2", 100*time.Second))
+	assert.Nil(t, setup.RestartNode("n1"))
+
+	_, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, uint64(2), version)
+
+	setup.Shutdown()
+}
+
+func TestClientCompareAndSetReplicated(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	swapped, version, err := setup.kv.CompareAndSet(setup.ctx, "abc", "first", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(1), version)
+
+	val, version, wasFound, err := setup.kv.GetWithVersion(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "first", val)
+	assert.Equal(t, uint64(1), version)
+
+	swapped, version, err = setup.kv.CompareAndSet(setup.ctx, "abc", "second", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	assert.Equal(t, uint64(1), version)
+
+	// diverge the replicas: only n1 sees this write
+	assert.Nil(t, setup.NodeSet("n1", "abc", "n1-only", 10*time.Second))
+	_, _, err = setup.kv.CompareAndSet(setup.ctx, "abc", "third", 10*time.Second, 2)
+	assertErrorWithCode(t, err, codes.Aborted)
+
+	setup.Shutdown()
+}
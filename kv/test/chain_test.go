@@ -0,0 +1,182 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for chain replication.
+
+func makeChainSetup(shardMap kv.ShardMapState) *TestSetup {
+	setup := MakeTestSetupWithOptions(shardMap, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{ChainReplication: true}
+	})
+	setup.kv = kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{ChainReplication: true})
+	return setup
+}
+
+func assertLocalOnNodes(t *testing.T, setup *TestSetup, nodes []string, key string, expected string, expectedFound bool) {
+	for _, node := range nodes {
+		val, found := localValue(setup, node, key)
+		assert.Equal(t, expectedFound, found, node)
+		assert.Equal(t, expected, val, node)
+	}
+}
+
+func notPrimaryTarget(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if notPrimary, ok := detail.(*proto.NotPrimary); ok {
+			return notPrimary.Primary
+		}
+	}
+	return ""
+}
+
+func TestChainWritesFlowDownChain(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	assertLocalOnNodes(t, setup, []string{"n1", "n2", "n3"}, "abc", "123", true)
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["chain_writes_forwarded"])
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["chain_writes_applied"])
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["chain_writes_forwarded"])
+	assert.Equal(t, int64(1), setup.nodes["n3"].Stats()["chain_writes_applied"])
+
+	val, wasFound, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	assert.Nil(t, setup.Delete("abc"))
+	assertLocalOnNodes(t, setup, []string{"n1", "n2", "n3"}, "abc", "", false)
+
+	setup.Shutdown()
+}
+
+func TestChainHeadWritesTailReads(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+
+	err := setup.NodeSet("n2", "abc", "456", 10*time.Second)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, "n1", notPrimaryTarget(err))
+	for _, node := range []string{"n1", "n2"} {
+		_, _, err = setup.NodeGet(node, "abc")
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, "n3", notPrimaryTarget(err))
+	}
+	val, wasFound, err := setup.NodeGet("n3", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	// a client with an outdated ShardMap follows the redirects
+	stale := copyShardMapState(setup.shardMap.GetState())
+	stale.ShardsToNodes[1] = []string{"n2", "n3", "n1"}
+	staleMap := &kv.ShardMap{}
+	staleMap.Update(&stale)
+	client := kv.MakeKvWithOptions(staleMap, &setup.clientPool, kv.KvOptions{ChainReplication: true})
+	assert.Nil(t, client.Set(setup.ctx, "abc", "456", 10*time.Second))
+	val, _, err = client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "456", val)
+	assert.Equal(t, int64(2), client.Stats()["primary_redirects"])
+
+	setup.Shutdown()
+}
+
+func TestChainReplicatesEveryKindOfWrite(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+	nodes := []string{"n1", "n2", "n3"}
+
+	swapped, _, err := setup.kv.CompareAndSet(setup.ctx, "abc", "1", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	value, err := setup.kv.Increment(setup.ctx, "abc", 5, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), value)
+	assertLocalOnNodes(t, setup, nodes, "abc", "6", true)
+
+	wasFound, err := setup.kv.Persist(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	ttl, _, err := setup.kv.GetTTL(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, kv.NoExpiry, ttl)
+
+	errs := setup.kv.MultiSet(setup.ctx, map[string]string{"x": "1", "y": "2"}, 10*time.Second)
+	assert.Empty(t, errs)
+	assertLocalOnNodes(t, setup, nodes, "y", "2", true)
+	results := setup.kv.MultiGet(setup.ctx, []string{"x", "y"})
+	assert.Nil(t, results["x"].Err)
+	assert.Equal(t, "1", results["x"].Value)
+
+	setup.Shutdown()
+}
+
+func TestChainRemovedMiddleNodeInFlight(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	// the write reaches n2 only after it was removed from the chain
+	setup.clientPool.AddLatencyInjection("n2", 200*time.Millisecond)
+	done := make(chan error)
+	go func() {
+		done <- setup.Set("abc", "123", 10*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n3"}})
+
+	assert.Nil(t, <-done)
+	assertLocalOnNodes(t, setup, []string{"n1", "n3"}, "abc", "123", true)
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["chain_writes_resent"])
+	val, _, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "123", val)
+
+	setup.Shutdown()
+}
+
+func TestChainRemovedTailInFlight(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	setup.clientPool.AddLatencyInjection("n3", 200*time.Millisecond)
+	done := make(chan error)
+	go func() {
+		done <- setup.Set("abc", "123", 10*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+
+	// n2 is the tail now, which completes the write
+	assert.Nil(t, <-done)
+	val, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	setup.Shutdown()
+}
+
+func TestChainFailsWithoutSuccessor(t *testing.T) {
+	setup := makeChainSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	err := setup.Set("abc", "123", 10*time.Second)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["chain_write_failures"])
+
+	// the chain works again once the ShardMap drops the node
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+	assert.Nil(t, setup.Set("abc", "456", 10*time.Second))
+	val, _, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "456", val)
+
+	setup.Shutdown()
+}
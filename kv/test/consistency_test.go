@@ -0,0 +1,176 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for the read and write consistency levels of the client.
+
+// Sets abc to "old" everywhere, then to "new" on every node but n1 (without
+// hinted handoff, so n1 stays stale)
+func makeStaleReplica(t *testing.T, setup *TestSetup) {
+	assert.Nil(t, setup.Set("abc", "old", 10*time.Second))
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		WriteConsistency:     kv.ConsistencyQuorum,
+		DisableHintedHandoff: true,
+	})
+	whileDown(t, setup, "n1", func() {
+		assert.Nil(t, client.Set(setup.ctx, "abc", "new", 10*time.Second))
+	})
+}
+
+/*
+ * Runs `write` while every RPC to `node` fails. A write which does not wait
+ * for every replica may return before its call to the node is made, so this
+ * waits for that call too: otherwise it could still reach the node afterwards.
+ */
+func whileDown(t *testing.T, setup *TestSetup, node string, write func()) {
+	setup.clientPool.ClearRequestsSent(node)
+	setup.clientPool.OverrideRpcError(node, status.Errorf(codes.Unavailable, "down"))
+	write()
+	// the call checks for the error as soon as it counts itself
+	assert.Eventually(t, func() bool {
+		return setup.clientPool.GetRequestsSent(node) > 0
+	}, time.Second, time.Millisecond)
+	setup.clientPool.ClearRpcOverrides(node)
+}
+
+func TestConsistencyQuorumWriteToleratesMinorityFailure(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	// the default write level is ALL
+	assert.NotNil(t, setup.Set("abc", "123", 10*time.Second))
+
+	quorum := kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum)
+	assert.Nil(t, setup.kv.Set(quorum, "abc", "456", 10*time.Second))
+	for _, node := range []string{"n1", "n2"} {
+		val, wasFound, err := setup.NodeGet(node, "abc")
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, "456", val)
+	}
+	assert.Nil(t, setup.kv.Delete(quorum, "abc"))
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestConsistencyQuorumWriteFailsWithoutMajority(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	err := setup.kv.Set(kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum), "abc", "123", 10*time.Second)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Nil(t, setup.kv.Set(kv.WithConsistency(setup.ctx, kv.ConsistencyOne), "abc", "123", 10*time.Second))
+
+	setup.Shutdown()
+}
+
+func TestConsistencyQuorumReadReturnsNewest(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	makeStaleReplica(t, setup)
+	quorum := kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum)
+
+	// reads at ONE go round-robin, so one of three lands on n1
+	seen := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		val, wasFound, err := setup.Get("abc")
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		seen[val]++
+	}
+	assert.Equal(t, map[string]int{"old": 1, "new": 2}, seen)
+
+	// any two replicas include one with the new value
+	for i := 0; i < 6; i++ {
+		val, wasFound, err := setup.kv.Get(quorum, "abc")
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, "new", val)
+
+		bytes, wasFound, err := setup.kv.GetBytes(quorum, []byte("abc"))
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, []byte("new"), bytes)
+	}
+
+	setup.Shutdown()
+}
+
+func TestConsistencyQuorumReadFailover(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+
+	quorum := kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum)
+	setup.clientPool.OverrideRpcError("n1", status.Errorf(codes.Unavailable, "down"))
+	for i := 0; i < 6; i++ {
+		val, wasFound, err := setup.kv.Get(quorum, "abc")
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, "123", val)
+	}
+
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	_, _, err := setup.kv.Get(quorum, "abc")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, _, err = setup.kv.Get(kv.WithConsistency(setup.ctx, kv.ConsistencyAll), "abc")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	val, wasFound, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	setup.Shutdown()
+}
+
+func TestConsistencyKvDefaults(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		ReadConsistency:  kv.ConsistencyQuorum,
+		WriteConsistency: kv.ConsistencyQuorum,
+	})
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	assert.Nil(t, client.Set(setup.ctx, "abc", "123", 10*time.Second))
+	val, wasFound, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	// per-call levels override the defaults
+	all := kv.WithConsistency(setup.ctx, kv.ConsistencyAll)
+	assert.NotNil(t, client.Set(all, "abc", "456", 10*time.Second))
+	_, _, err = client.Get(all, "abc")
+	assert.NotNil(t, err)
+
+	setup.Shutdown()
+}
+
+func TestConsistencyQuorumWriteDoesNotWaitForSlowReplica(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.AddLatencyInjection("n3", 500*time.Millisecond)
+
+	start := time.Now()
+	assert.Nil(t, setup.kv.Set(kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum), "abc", "123", 10*time.Second))
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+
+	// the slow replica still gets the write
+	time.Sleep(700 * time.Millisecond)
+	val, wasFound, err := setup.NodeGet("n3", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,160 @@
+package kvtest
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for the atomic Increment/Decrement counters.
+
+func TestServerIncrementDecrement(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	// a missing key counts as 0
+	value, err := setup.NodeIncrement("n1", "abc", 5, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), value)
+
+	value, err = setup.NodeDecrement("n1", "abc", 7, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), value)
+
+	// stored as a plain decimal string, so Get and Set interoperate
+	val, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "-2", val)
+	assert.Equal(t, uint64(2), version)
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "40", 10*time.Second))
+	value, err = setup.NodeIncrement("n1", "abc", 2, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), value)
+
+	_, err = setup.NodeIncrement("n1", "", 1, 10*time.Second)
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+
+	setup.Shutdown()
+}
+
+func TestServerIncrementErrors(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	assert.Nil(t, setup.NodeSet("n1", "text", "hello", 10*time.Second))
+	_, err := setup.NodeIncrement("n1", "text", 1, 10*time.Second)
+	assertErrorWithCode(t, err, codes.FailedPrecondition)
+	val, _, err := setup.NodeGet("n1", "text")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", val)
+
+	_, err = setup.NodeIncrement("n1", "big", math.MaxInt64, 10*time.Second)
+	assert.Nil(t, err)
+	_, err = setup.NodeIncrement("n1", "big", 1, 10*time.Second)
+	assertErrorWithCode(t, err, codes.OutOfRange)
+
+	_, err = setup.NodeDecrement("n1", "small", math.MaxInt64, 10*time.Second)
+	assert.Nil(t, err)
+	_, err = setup.NodeDecrement("n1", "small", 2, 10*time.Second)
+	assertErrorWithCode(t, err, codes.OutOfRange)
+	value, err := setup.NodeDecrement("n1", "small", 1, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(math.MinInt64), value)
+
+	setup.Shutdown()
+}
+
+func TestServerIncrementTtlOnlyForNewKeys(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	_, err := setup.NodeIncrement("n1", "abc", 1, 300*time.Millisecond)
+	assert.Nil(t, err)
+	// does not extend the expiry of the existing counter
+	_, err = setup.NodeIncrement("n1", "abc", 1, 10*time.Second)
+	assert.Nil(t, err)
+
+	time.Sleep(400 * time.Millisecond)
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	// an expired counter starts again from 0
+	value, err := setup.NodeIncrement("n1", "abc", 1, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), value)
+
+	setup.Shutdown()
+}
+
+func TestServerIncrementWithoutTtl(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	// a counter created without a ttl never expires
+	_, err := setup.NodeIncrement("n1", "abc", 1, 0)
+	assert.Nil(t, err)
+	value, err := setup.NodeIncrement("n1", "abc", 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), value)
+
+	time.Sleep(100 * time.Millisecond)
+	val, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "2", val)
+
+	setup.Shutdown()
+}
+
+func TestServerIncrementConcurrent(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := setup.NodeIncrement("n1", "abc", 1, 10*time.Second)
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, _, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "1000", val)
+
+	setup.Shutdown()
+}
+
+func TestClientIncrementReplicated(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	for i := 1; i <= 3; i++ {
+		value, err := setup.kv.Increment(setup.ctx, "abc", 10, 10*time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(10*i), value)
+	}
+	value, err := setup.kv.Decrement(setup.ctx, "abc", 5, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(25), value)
+
+	// every replica applied every delta
+	for _, node := range []string{"n1", "n2"} {
+		val, _, err := setup.NodeGet(node, "abc")
+		assert.Nil(t, err)
+		assert.Equal(t, "25", val)
+	}
+
+	setup.clientPool.OverrideRpcError("n2", errors.New("oh no!"))
+	_, err = setup.kv.Increment(setup.ctx, "abc", 1, 10*time.Second)
+	assert.NotNil(t, err)
+
+	setup.Shutdown()
+}
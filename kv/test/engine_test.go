@@ -0,0 +1,46 @@
+package kvtest
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"testing"
+
+	"cs426.yale.edu/lab4/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * The rest of the suite runs on the default storage engine. Runs it again, in a
+ * new process, with --storage-engine set to each of the other engines, so every
+ * test is checked against every engine without keeping a list of them.
+ */
+func TestStorageEngines(t *testing.T) {
+	if *testStorageEngine != "" {
+		t.Skip("the suite runs on a single engine when --storage-engine is set")
+	}
+	for _, engine := range kv.StorageEngines[1:] {
+		t.Run(engine, func(t *testing.T) {
+			args := []string{"-storage-engine=" + engine}
+			for _, name := range []string{"test.run", "test.skip", "test.short", "log-level"} {
+				if f := flag.Lookup(name); f != nil {
+					args = append(args, "-"+name+"="+f.Value.String())
+				}
+			}
+			output, err := exec.Command(os.Args[0], args...).CombinedOutput()
+			if err != nil {
+				t.Fatalf("suite failed on storage engine %s: %v\n%s", engine, err, output)
+			}
+		})
+	}
+}
+
+func TestStorageEngineUnknown(t *testing.T) {
+	shardMap := kv.ShardMap{}
+	state := MakeBasicOneShard()
+	shardMap.Update(&state)
+	_, err := kv.MakeKvServerWithOptions("n1", &shardMap, &TestClientPool{}, kv.KvServerOptions{
+		StorageEngine: "nope",
+	})
+	assert.NotNil(t, err)
+}
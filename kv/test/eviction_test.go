@@ -0,0 +1,188 @@
+package kvtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for servers with a memory budget (MemoryBudgetBytes + EvictionPolicy).
+
+func makeBudgetOptions(budget int64, policy string) func(string) kv.KvServerOptions {
+	return func(string) kv.KvServerOptions {
+		return kv.KvServerOptions{MemoryBudgetBytes: budget, EvictionPolicy: policy}
+	}
+}
+
+// value such that len(key)+len(value) == size
+func valueOfSize(key string, size int) string {
+	return strings.Repeat("v", size-len(key))
+}
+
+func assertFound(t *testing.T, setup *TestSetup, key string, expected bool) {
+	_, wasFound, err := setup.NodeGet("n1", key)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, wasFound, "key %s", key)
+}
+
+func TestEvictionLRU(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(1000, kv.EvictLRU))
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("k%d", i)
+		assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), 100*time.Second))
+	}
+	assert.Equal(t, int64(0), setup.nodes["n1"].Stats()["evictions"])
+
+	// k0 is now the most recently used, so k1 is the one to go
+	assertFound(t, setup, "k0", true)
+	assert.Nil(t, setup.NodeSet("n1", "k10", valueOfSize("k10", 100), 100*time.Second))
+
+	assertFound(t, setup, "k0", true)
+	assertFound(t, setup, "k1", false)
+	assertFound(t, setup, "k10", true)
+	stats := setup.nodes["n1"].Stats()
+	assert.Equal(t, int64(1), stats["evictions"])
+	assert.Equal(t, int64(100), stats["evicted_bytes"])
+	assert.Equal(t, int64(1000), stats["memory_bytes"])
+
+	setup.Shutdown()
+}
+
+func TestEvictionLFU(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(300, kv.EvictLFU))
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), 100*time.Second))
+	}
+	assertFound(t, setup, "a", true)
+	assertFound(t, setup, "a", true)
+	assertFound(t, setup, "c", true)
+
+	assert.Nil(t, setup.NodeSet("n1", "d", valueOfSize("d", 100), 100*time.Second))
+	assertFound(t, setup, "b", false)
+	assertFound(t, setup, "a", true)
+	assertFound(t, setup, "c", true)
+	assertFound(t, setup, "d", true)
+
+	setup.Shutdown()
+}
+
+func TestEvictionLFUKeepsNewEntries(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(300, kv.EvictLFU))
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), 100*time.Second))
+	}
+	// none of them was read: the oldest goes, not the one just written
+	assert.Nil(t, setup.NodeSet("n1", "d", valueOfSize("d", 100), 100*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "e", valueOfSize("e", 100), 100*time.Second))
+	assertFound(t, setup, "a", false)
+	assertFound(t, setup, "b", false)
+	assertFound(t, setup, "c", true)
+	assertFound(t, setup, "d", true)
+	assertFound(t, setup, "e", true)
+
+	setup.Shutdown()
+}
+
+func TestEvictionKeepsTombstones(t *testing.T) {
+	for _, policy := range []string{kv.EvictLRU, kv.EvictLFU, kv.EvictTTLSoonest} {
+		setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(300, policy))
+
+		for _, key := range []string{"a", "b", "c"} {
+			assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), time.Second))
+		}
+		assert.Nil(t, setup.NodeDelete("n1", "a"))
+		// the tombstone does not count against the budget
+		assert.Equal(t, int64(200), setup.nodes["n1"].Stats()["memory_bytes"])
+
+		for _, key := range []string{"d", "e"} {
+			assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), 100*time.Second))
+		}
+		assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["evictions"], policy)
+		tombstone := shardContents(t, setup, "n1")["a"]
+		assert.NotNil(t, tombstone, policy)
+		assert.True(t, tombstone.Deleted, policy)
+
+		setup.Shutdown()
+	}
+}
+
+func TestEvictionTTLSoonest(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(300, kv.EvictTTLSoonest))
+
+	assert.Nil(t, setup.NodeSet("n1", "a", valueOfSize("a", 100), 100*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "b", valueOfSize("b", 100), 10*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "c", valueOfSize("c", 100), 50*time.Second))
+
+	assert.Nil(t, setup.NodeSet("n1", "d", valueOfSize("d", 100), 100*time.Second))
+	assertFound(t, setup, "b", false)
+	assertFound(t, setup, "a", true)
+	assertFound(t, setup, "c", true)
+	assertFound(t, setup, "d", true)
+
+	setup.Shutdown()
+}
+
+func TestEvictionNeverFailsOversizedSet(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(100, kv.EvictLRU))
+
+	assert.Nil(t, setup.NodeSet("n1", "a", valueOfSize("a", 50), 100*time.Second))
+	// larger than the whole budget: evicts everything else, but is still stored
+	assert.Nil(t, setup.NodeSet("n1", "big", valueOfSize("big", 500), 100*time.Second))
+	assertFound(t, setup, "a", false)
+	assertFound(t, setup, "big", true)
+
+	response, err := setup.nodes["n1"].GetStats(context.Background(), &proto.GetStatsRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(500), response.Counters["memory_bytes"])
+	assert.Equal(t, int64(100), response.Counters["memory_budget_bytes"])
+	assert.Equal(t, int64(1), response.Counters["evictions"])
+
+	setup.Shutdown()
+}
+
+func TestEvictionAcrossShards(t *testing.T) {
+	budget := int64(5000)
+	setup := MakeTestSetupWithOptions(MakeMultiShardSingleNode(), makeBudgetOptions(budget, kv.EvictLRU))
+
+	keys := RandomKeys(500, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.NodeSet("n1", key, valueOfSize(key, 100), 100*time.Second))
+	}
+	// per-shard eviction plus the background loop keep the node within budget
+	assert.Eventually(t, func() bool {
+		return setup.nodes["n1"].Stats()["memory_bytes"] <= budget
+	}, time.Second, 10*time.Millisecond)
+
+	found := 0
+	for _, key := range keys {
+		_, wasFound, err := setup.NodeGet("n1", key)
+		assert.Nil(t, err)
+		if wasFound {
+			found++
+		}
+	}
+	assert.Equal(t, int64(found*100), setup.nodes["n1"].Stats()["memory_bytes"])
+	assert.Equal(t, int64(500-found), setup.nodes["n1"].Stats()["evictions"])
+
+	setup.Shutdown()
+}
+
+func TestEvictionUnknownPolicy(t *testing.T) {
+	shardMap := kv.ShardMap{}
+	state := MakeBasicOneShard()
+	shardMap.Update(&state)
+	_, err := kv.MakeKvServerWithOptions("n1", &shardMap, &TestClientPool{}, kv.KvServerOptions{
+		MemoryBudgetBytes: 100,
+		EvictionPolicy:    "random",
+	})
+	assert.NotNil(t, err)
+}
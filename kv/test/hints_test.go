@@ -0,0 +1,196 @@
+package kvtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for hinted handoff of writes which missed a replica.
+
+// Sum of a counter over the servers of a setup
+func totalStat(setup *TestSetup, name string) int64 {
+	total := int64(0)
+	for _, node := range setup.nodes {
+		total += node.Stats()[name]
+	}
+	return total
+}
+
+func TestHintedHandoffReplaysSet(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	quorum := kv.WithConsistency(setup.ctx, kv.ConsistencyQuorum)
+	assert.Nil(t, setup.kv.Set(quorum, "abc", "123", 10*time.Second))
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_pending") == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), setup.kv.Stats()["hints_stored"])
+
+	// later writes to the key are covered by the same hint
+	assert.Nil(t, setup.kv.Set(quorum, "abc", "456", 10*time.Second))
+	assert.Eventually(t, func() bool {
+		return setup.kv.Stats()["hints_stored"] == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), totalStat(setup, "hints_pending"))
+
+	// still down: the hint waits
+	time.Sleep(1500 * time.Millisecond)
+	_, wasFound, err := setup.NodeGet("n3", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.clientPool.ClearRpcOverrides("n3")
+	assert.Eventually(t, func() bool {
+		val, _, err := setup.NodeGet("n3", "abc")
+		return err == nil && val == "456"
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(1), totalStat(setup, "hints_replayed"))
+	assert.Equal(t, int64(0), totalStat(setup, "hints_pending"))
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffReplaysDelete(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+	// fails at the default level (ALL), but still applied on n1 and n2
+	assert.NotNil(t, setup.Delete("abc"))
+	setup.clientPool.ClearRpcOverrides("n3")
+
+	assert.Eventually(t, func() bool {
+		_, wasFound, err := setup.NodeGet("n3", "abc")
+		return err == nil && !wasFound
+	}, 3*time.Second, 50*time.Millisecond)
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffNotForRejectedWrites(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Aborted, "no"))
+
+	assert.NotNil(t, setup.Set("abc", "123", 10*time.Second))
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, setup.kv.Stats())
+	assert.Equal(t, int64(0), totalStat(setup, "hints_pending"))
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffDisabled(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{DisableHintedHandoff: true})
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	assert.NotNil(t, client.Set(setup.ctx, "abc", "123", 10*time.Second))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(0), totalStat(setup, "hints_pending"))
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffDroppedWhenShardMoves(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+	assert.NotNil(t, setup.Set("abc", "123", 10*time.Second))
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_pending") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_pending") == 0
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(1), totalStat(setup, "hints_dropped"))
+	assert.Equal(t, int64(0), totalStat(setup, "hints_replayed"))
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffExpires(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeTwoNodeBothAssignedSingleShard(), func(string) kv.KvServerOptions {
+		return kv.KvServerOptions{HintTTL: 100 * time.Millisecond}
+	})
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	assert.NotNil(t, setup.Set("abc", "123", 10*time.Second))
+
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_expired") == 1
+	}, 3*time.Second, 50*time.Millisecond)
+	setup.clientPool.ClearRpcOverrides("n2")
+	time.Sleep(1500 * time.Millisecond)
+	_, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffBounded(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeTwoNodeBothAssignedSingleShard(), func(string) kv.KvServerOptions {
+		return kv.KvServerOptions{MaxHints: 2}
+	})
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NotNil(t, setup.Set(key, "123", 10*time.Second))
+	}
+	assert.Eventually(t, func() bool {
+		stats := setup.kv.Stats()
+		return stats["hints_stored"] == 2 && stats["hint_failures"] == 2
+	}, time.Second, 10*time.Millisecond)
+	stats := setup.nodes["n1"].Stats()
+	assert.Equal(t, int64(2), stats["hints_pending"])
+	assert.Equal(t, int64(2), stats["hints_dropped"])
+
+	setup.Shutdown()
+}
+
+func TestServerStoreHintValidatesNode(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	for _, node := range []string{"n1", "n3"} {
+		_, err := setup.nodes["n1"].StoreHint(context.Background(), &proto.StoreHintRequest{Node: node, Key: []byte("abc")})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	}
+	_, err := setup.nodes["n1"].StoreHint(context.Background(), &proto.StoreHintRequest{Node: "n2", Key: []byte("abc")})
+	assert.Nil(t, err)
+
+	setup.Shutdown()
+}
+
+func TestHintedHandoffDroppedWhenEntryGone(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n2", status.Errorf(codes.Unavailable, "down"))
+	one := kv.WithConsistency(setup.ctx, kv.ConsistencyOne)
+	assert.Nil(t, setup.kv.Set(one, "abc", "123", 200*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_pending") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the hinted value expires on n1, and n2 gets a newer one meanwhile
+	time.Sleep(300 * time.Millisecond)
+	assert.Nil(t, setup.NodeSet("n2", "abc", "456", 10*time.Second))
+	setup.clientPool.ClearRpcOverrides("n2")
+
+	assert.Eventually(t, func() bool {
+		return totalStat(setup, "hints_dropped") == 1
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(0), totalStat(setup, "hints_replayed"))
+	val, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "456", val)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,175 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for last-writer-wins ordering of writes by their timestamps.
+
+func nodeSetAt(t *testing.T, setup *TestSetup, node string, key string, value string, timestamp uint64) {
+	_, err := setup.nodes[node].Set(setup.ctx, &proto.SetRequest{Key: key, Value: value, TtlMs: 10000, Timestamp: timestamp})
+	assert.Nil(t, err)
+}
+
+func nodeGetResponse(t *testing.T, setup *TestSetup, node string, key string) *proto.GetResponse {
+	response, err := setup.nodes[node].Get(setup.ctx, &proto.GetRequest{Key: key})
+	assert.Nil(t, err)
+	return response
+}
+
+func TestLastWriterWinsWhateverTheOrder(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+	now := uint64(time.Now().UnixNano())
+
+	// concurrent Sets reach the replicas in different orders
+	nodeSetAt(t, setup, "n1", "abc", "first", now)
+	nodeSetAt(t, setup, "n1", "abc", "second", now+1)
+	nodeSetAt(t, setup, "n2", "abc", "second", now+1)
+	nodeSetAt(t, setup, "n2", "abc", "first", now)
+
+	for _, node := range []string{"n1", "n2"} {
+		response := nodeGetResponse(t, setup, node, "abc")
+		assert.Equal(t, "second", response.Value, node)
+		assert.Equal(t, now+1, response.Timestamp, node)
+	}
+	assert.Equal(t, int64(0), setup.nodes["n1"].Stats()["stale_writes_ignored"])
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["stale_writes_ignored"])
+
+	setup.Shutdown()
+}
+
+func TestLastWriterWinsReadModifyWriteStampedAfterValue(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	// written by a client whose clock is ahead of ours
+	future := uint64(time.Now().Add(time.Hour).UnixNano())
+	nodeSetAt(t, setup, "n1", "counter", "1", future)
+
+	value, err := setup.NodeIncrement("n1", "counter", 1, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), value)
+	response := nodeGetResponse(t, setup, "n1", "counter")
+	assert.Greater(t, response.Timestamp, future)
+
+	swapped, _, err := setup.NodeCompareAndSet("n1", "counter", "10", 10*time.Second, response.Version)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Greater(t, nodeGetResponse(t, setup, "n1", "counter").Timestamp, response.Timestamp)
+
+	// the server saw the future timestamp, so the ones it picks are later
+	assert.Nil(t, setup.NodeSet("n1", "counter", "20", 10*time.Second))
+	assert.Equal(t, "20", nodeGetResponse(t, setup, "n1", "counter").Value)
+
+	setup.Shutdown()
+}
+
+func TestLastWriterWinsClientClockFollowsReads(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+	future := uint64(time.Now().Add(time.Hour).UnixNano())
+	nodeSetAt(t, setup, "n1", "abc", "ahead", future)
+	nodeSetAt(t, setup, "n2", "abc", "ahead", future)
+
+	// a client which never saw the value cannot replace it
+	assert.Nil(t, setup.Set("abc", "lost", 10*time.Second))
+	val, _, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "ahead", val)
+
+	// reading it moved the client's clock past it
+	assert.Nil(t, setup.Set("abc", "after", 10*time.Second))
+	for _, node := range []string{"n1", "n2"} {
+		response := nodeGetResponse(t, setup, node, "abc")
+		assert.Equal(t, "after", response.Value, node)
+		assert.Greater(t, response.Timestamp, future, node)
+	}
+
+	setup.Shutdown()
+}
+
+func TestLastWriterWinsSurvivesShardMove(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	})
+	now := uint64(time.Now().UnixNano())
+	nodeSetAt(t, setup, "n1", "abc", "moved", now)
+
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+	response := nodeGetResponse(t, setup, "n2", "abc")
+	assert.Equal(t, "moved", response.Value)
+	assert.Equal(t, now, response.Timestamp)
+
+	// a write older than the copied value is still ignored on the new node
+	nodeSetAt(t, setup, "n2", "abc", "stale", now-1)
+	assert.Equal(t, "moved", nodeGetResponse(t, setup, "n2", "abc").Value)
+
+	setup.Shutdown()
+}
+
+func TestLastWriterWinsClientStampsEveryWrite(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	// each replica records the timestamp the client picked, whatever the write
+	assertSameTimestamp := func(after uint64, write string) uint64 {
+		n1 := nodeGetResponse(t, setup, "n1", "abc")
+		n2 := nodeGetResponse(t, setup, "n2", "abc")
+		assert.Equal(t, n1.Timestamp, n2.Timestamp, write)
+		assert.Greater(t, n1.Timestamp, after, write)
+		return n1.Timestamp
+	}
+	assert.Nil(t, setup.Set("abc", "1", 10*time.Second))
+	timestamp := assertSameTimestamp(0, "set")
+
+	swapped, _, err := setup.kv.CompareAndSet(setup.ctx, "abc", "2", 10*time.Second, 1)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	timestamp = assertSameTimestamp(timestamp, "compare and set")
+
+	_, err = setup.kv.Increment(setup.ctx, "abc", 1, 10*time.Second)
+	assert.Nil(t, err)
+	timestamp = assertSameTimestamp(timestamp, "increment")
+
+	_, err = setup.kv.Decrement(setup.ctx, "abc", 1, 10*time.Second)
+	assert.Nil(t, err)
+	timestamp = assertSameTimestamp(timestamp, "decrement")
+
+	// expiry changes are ordered like writes, keeping the value and version
+	_, err = setup.kv.Touch(setup.ctx, "abc", 20*time.Second)
+	assert.Nil(t, err)
+	timestamp = assertSameTimestamp(timestamp, "touch")
+	_, err = setup.kv.Persist(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assertSameTimestamp(timestamp, "persist")
+	response := nodeGetResponse(t, setup, "n1", "abc")
+	assert.Equal(t, "2", response.Value)
+	assert.Equal(t, uint64(4), response.Version)
+
+	setup.Shutdown()
+}
+
+func TestLastWriterWinsMissedTouchRepaired(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		ReadConsistency: kv.ConsistencyAll,
+		ReadRepair:      true,
+	})
+	assert.Nil(t, client.Set(setup.ctx, "abc", "123", 10*time.Second))
+	whileDown(t, setup, "n1", func() {
+		_, err := client.Persist(setup.ctx, "abc")
+		assert.NotNil(t, err)
+	})
+	assert.False(t, nodeGetTTL(t, setup, "n1", "abc").NoExpiry)
+
+	_, _, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return nodeGetTTL(t, setup, "n1", "abc").NoExpiry
+	}, time.Second, 10*time.Millisecond)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,150 @@
+package kvtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for the admin calls reporting on, cancelling and retrying shard copies.
+
+func nodeAdmin(setup *TestSetup, node string) *kv.KvAdminImpl {
+	return kv.MakeKvAdmin(setup.nodes[node])
+}
+
+func migrationStatus(t *testing.T, setup *TestSetup, node string) []*proto.ShardMigrationStatus {
+	response, err := nodeAdmin(setup, node).GetMigrationStatus(setup.ctx, &proto.GetMigrationStatusRequest{})
+	assert.Nil(t, err)
+	return response.Shards
+}
+
+func makeMigrationSetup(copyTimeout time.Duration) *TestSetup {
+	return MakeTestSetupWithOptions(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	}, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{ShardCopyTimeout: copyTimeout}
+	})
+}
+
+func TestMigrationStatusReportsCopy(t *testing.T) {
+	setup := makeMigrationSetup(0)
+	keys := RandomKeys(20, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set(key, "value", 10*time.Second))
+	}
+	// nothing to copy the shard from when the cluster started
+	shards := migrationStatus(t, setup, "n1")
+	assert.Equal(t, 1, len(shards))
+	assert.Equal(t, proto.ShardMigrationStatus_NO_PEER, shards[0].State)
+	assert.Equal(t, 0, len(migrationStatus(t, setup, "n2")))
+
+	start := time.Now().UnixMilli()
+	done := startSlowShardCopy(t, setup)
+	shards = migrationStatus(t, setup, "n2")
+	assert.Equal(t, 1, len(shards))
+	assert.Equal(t, proto.ShardMigrationStatus_COPYING, shards[0].State)
+	assert.Equal(t, "n1", shards[0].Source)
+	assert.GreaterOrEqual(t, shards[0].StartTimeMs, start)
+
+	<-done
+	shards = migrationStatus(t, setup, "n2")
+	assert.Equal(t, int32(1), shards[0].Shard)
+	assert.Equal(t, proto.ShardMigrationStatus_ACTIVE, shards[0].State)
+	assert.Equal(t, int64(len(keys)), shards[0].KeysCopied)
+	assert.Equal(t, int64(len(keys)*(10+len("value"))), shards[0].BytesCopied)
+	assert.Empty(t, shards[0].Error)
+
+	setup.Shutdown()
+}
+
+func TestMigrationFailedCopyRetried(t *testing.T) {
+	setup := makeMigrationSetup(0)
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+
+	setup.clientPool.OverrideRpcError("n1", errors.New("unreachable"))
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+	shards := migrationStatus(t, setup, "n2")
+	assert.Equal(t, proto.ShardMigrationStatus_FAILED, shards[0].State)
+	assert.Contains(t, shards[0].Error, "unreachable")
+	// the shard is served, but empty
+	_, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.clientPool.ClearRpcOverrides("n1")
+	_, err = nodeAdmin(setup, "n2").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return migrationStatus(t, setup, "n2")[0].State == proto.ShardMigrationStatus_ACTIVE
+	}, time.Second, 5*time.Millisecond)
+	val, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["shard_copy_retries"])
+
+	// only shards the node hosts, from other nodes
+	_, err = nodeAdmin(setup, "n1").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1, Source: "n1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	setup.UpdateShardMapping(map[int][]string{1: {"n1"}})
+	_, err = nodeAdmin(setup, "n2").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 0, len(migrationStatus(t, setup, "n2")))
+
+	setup.Shutdown()
+}
+
+func TestMigrationTimedOutCopyFails(t *testing.T) {
+	setup := makeMigrationSetup(100 * time.Millisecond)
+	setup.clientPool.AddLatencyInjection("n1", 300*time.Millisecond)
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+
+	shards := migrationStatus(t, setup, "n2")
+	assert.Equal(t, proto.ShardMigrationStatus_FAILED, shards[0].State)
+	assert.Equal(t, "n1", shards[0].Source)
+	assert.NotEmpty(t, shards[0].Error)
+
+	setup.Shutdown()
+}
+
+func TestMigrationCancelCopy(t *testing.T) {
+	setup := makeMigrationSetup(0)
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	_, err := nodeAdmin(setup, "n2").CancelShardCopy(setup.ctx, &proto.CancelShardCopyRequest{Shard: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	done := startSlowShardCopy(t, setup)
+	_, err = nodeAdmin(setup, "n2").CancelShardCopy(setup.ctx, &proto.CancelShardCopyRequest{Shard: 1})
+	assert.Nil(t, err)
+	// only served shards can be retried
+	_, err = nodeAdmin(setup, "n2").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	<-done
+	shards := migrationStatus(t, setup, "n2")
+	assert.Equal(t, proto.ShardMigrationStatus_FAILED, shards[0].State)
+	assert.Equal(t, "copy cancelled", shards[0].Error)
+	_, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	// retried from the node we name, still slowly
+	_, err = nodeAdmin(setup, "n2").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1, Source: "n1"})
+	assert.Nil(t, err)
+	_, err = nodeAdmin(setup, "n2").RetryShardCopy(setup.ctx, &proto.RetryShardCopyRequest{Shard: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Eventually(t, func() bool {
+		_, wasFound, err := setup.NodeGet("n2", "abc")
+		return err == nil && wasFound
+	}, 2*time.Second, 5*time.Millisecond)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,199 @@
+package kvtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for servers started with a data directory (write-ahead log + snapshots).
+
+func makeDataDirOptions(t *testing.T, snapshotInterval time.Duration) func(string) kv.KvServerOptions {
+	baseDir := t.TempDir()
+	return func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{
+			DataDir:          filepath.Join(baseDir, nodeName),
+			SnapshotInterval: snapshotInterval,
+		}
+	}
+}
+
+func TestPersistenceRecoversAfterRestart(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 100*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "def", "456", 100*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "abc", "789", 100*time.Second))
+	assert.Nil(t, setup.NodeDelete("n1", "def"))
+
+	assert.Nil(t, setup.RestartNode("n1"))
+
+	val, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "789", val)
+
+	_, wasFound, err = setup.NodeGet("n1", "def")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestPersistenceDropsExpiredOnReplay(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.NodeSet("n1", "short", "1", 100*time.Millisecond))
+	assert.Nil(t, setup.NodeSet("n1", "long", "2", 100*time.Second))
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Nil(t, setup.RestartNode("n1"))
+
+	_, wasFound, err := setup.NodeGet("n1", "short")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	val, wasFound, err := setup.NodeGet("n1", "long")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "2", val)
+
+	setup.Shutdown()
+}
+
+func TestPersistenceRecoversFromSnapshotAndWal(t *testing.T) {
+	options := makeDataDirOptions(t, 100*time.Millisecond)
+	setup := MakeTestSetupWithOptions(MakeMultiShardSingleNode(), options)
+
+	keys := RandomKeys(200, 10)
+	for _, key := range keys[:100] {
+		assert.Nil(t, setup.NodeSet("n1", key, key+"-v1", 100*time.Second))
+	}
+	// wait for at least one checkpoint, then write some more on top of it
+	time.Sleep(300 * time.Millisecond)
+	for _, key := range keys[100:] {
+		assert.Nil(t, setup.NodeSet("n1", key, key+"-v2", 100*time.Second))
+	}
+
+	files, err := os.ReadDir(options("n1").DataDir)
+	assert.Nil(t, err)
+	snapshots := 0
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".snap") {
+			snapshots++
+		}
+	}
+	assert.Equal(t, 5, snapshots)
+
+	assert.Nil(t, setup.RestartNode("n1"))
+
+	for i, key := range keys {
+		expected := key + "-v1"
+		if i >= 100 {
+			expected = key + "-v2"
+		}
+		val, wasFound, err := setup.NodeGet("n1", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, expected, val)
+	}
+
+	setup.Shutdown()
+}
+
+// Appends half a frame to the newest WAL segment, as a crash mid-write would
+func tearWalTail(t *testing.T, dir string) {
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	newest := ""
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".log") && file.Name() > newest {
+			newest = file.Name()
+		}
+	}
+	wal, err := os.OpenFile(filepath.Join(dir, newest), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	_, err = wal.Write([]byte{42, 0, 0, 0, 1, 2})
+	assert.Nil(t, err)
+	assert.Nil(t, wal.Close())
+}
+
+func TestPersistenceTruncatesTornWalTail(t *testing.T) {
+	options := makeDataDirOptions(t, time.Minute)
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), options)
+
+	// torn after a record
+	assert.Nil(t, setup.NodeSet("n1", "abc", "1", 100*time.Second))
+	setup.StopNode("n1")
+	tearWalTail(t, options("n1").DataDir)
+	assert.Nil(t, setup.StartNode("n1"))
+	assert.Nil(t, setup.NodeSet("n1", "def", "2", 100*time.Second))
+
+	// torn before the first record of the segment we append to next
+	assert.Nil(t, setup.RestartNode("n1"))
+	setup.StopNode("n1")
+	tearWalTail(t, options("n1").DataDir)
+	assert.Nil(t, setup.StartNode("n1"))
+	assert.Nil(t, setup.NodeSet("n1", "ghi", "3", 100*time.Second))
+
+	// what was written after each restart is still read back after the next one
+	assert.Nil(t, setup.RestartNode("n1"))
+	for key, expected := range map[string]string{"abc": "1", "def": "2", "ghi": "3"} {
+		val, wasFound, err := setup.NodeGet("n1", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound, key)
+		assert.Equal(t, expected, val)
+	}
+
+	setup.Shutdown()
+}
+
+func TestPersistenceDropsUnassignedShards(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeTwoNodeBothAssignedSingleShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.Set("abc", "123", 100*time.Second))
+
+	// n1 comes back after the shard was moved away while it was down,
+	// so it must discard what it recovered from disk
+	setup.StopNode("n1")
+	setup.UpdateShardMapping(map[int][]string{1: {"n2"}})
+	assert.Nil(t, setup.StartNode("n1"))
+	_, _, err := setup.NodeGet("n1", "abc")
+	assertErrorWithCode(t, err, codes.NotFound)
+
+	// ... and that must be durable: if the shard comes back to n1 with no
+	// peer to copy from, the old value must not reappear
+	setup.StopNode("n1")
+	setup.UpdateShardMapping(map[int][]string{1: {"n1"}})
+	assert.Nil(t, setup.StartNode("n1"))
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestPersistenceKeepsRecoveredShardWithoutPeers(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeTwoNodeBothAssignedSingleShard(), makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.Set("abc", "123", 100*time.Second))
+
+	// the other replica goes away while n1 is down: n1's own copy on disk
+	// is the only one left
+	setup.StopNode("n1")
+	setup.UpdateShardMapping(map[int][]string{1: {"n1"}})
+	assert.Nil(t, setup.StartNode("n1"))
+
+	val, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,195 @@
+package kvtest
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for primary-backup replication.
+
+func makePrimaryBackupSetup(shardMap kv.ShardMapState) *TestSetup {
+	setup := MakeTestSetupWithOptions(shardMap, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{PrimaryBackup: true}
+	})
+	setup.kv = kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{PrimaryBackup: true})
+	return setup
+}
+
+func assertOnAllNodes(t *testing.T, setup *TestSetup, key string, expected string, expectedFound bool) {
+	for _, node := range []string{"n1", "n2", "n3"} {
+		val, wasFound, err := setup.NodeGet(node, key)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedFound, wasFound, node)
+		assert.Equal(t, expected, val, node)
+	}
+}
+
+func TestPrimaryBackupWritesGoThroughPrimary(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	assertOnAllNodes(t, setup, "abc", "123", true)
+	assert.Equal(t, int64(0), setup.nodes["n1"].Stats()["backup_writes_applied"])
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["backup_writes_applied"])
+	assert.Equal(t, int64(1), setup.nodes["n3"].Stats()["backup_writes_applied"])
+
+	// the backups keep the version the primary assigned
+	_, version, _, err := setup.NodeGetWithVersion("n3", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), version)
+
+	assert.Nil(t, setup.Delete("abc"))
+	assertOnAllNodes(t, setup, "abc", "", false)
+
+	setup.Shutdown()
+}
+
+func TestPrimaryBackupReplicatesEveryKindOfWrite(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	swapped, version, err := setup.kv.CompareAndSet(setup.ctx, "abc", "1", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(1), version)
+	value, err := setup.kv.Increment(setup.ctx, "abc", 5, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), value)
+	value, err = setup.kv.Decrement(setup.ctx, "abc", 2, 10*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), value)
+	assertOnAllNodes(t, setup, "abc", "4", true)
+
+	wasFound, err := setup.kv.Persist(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	for _, node := range []string{"n2", "n3"} {
+		response, err := setup.nodes[node].GetTTL(setup.ctx, &proto.GetTTLRequest{Key: "abc"})
+		assert.Nil(t, err)
+		assert.True(t, response.NoExpiry)
+	}
+
+	errs := setup.kv.MultiSet(setup.ctx, map[string]string{"x": "1", "y": "2"}, 10*time.Second)
+	assert.Empty(t, errs)
+	assertOnAllNodes(t, setup, "y", "2", true)
+	errs = setup.kv.MultiDelete(setup.ctx, []string{"x", "y"})
+	assert.Empty(t, errs)
+	assertOnAllNodes(t, setup, "x", "", false)
+
+	setup.Shutdown()
+}
+
+func TestPrimaryBackupBackupRejectsClientWrites(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	err := setup.NodeSet("n2", "abc", "123", 10*time.Second)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	details := status.Convert(err).Details()
+	assert.Len(t, details, 1)
+	notPrimary, ok := details[0].(*proto.NotPrimary)
+	assert.True(t, ok)
+	assert.Equal(t, "n1", notPrimary.Primary)
+	assert.Equal(t, int32(1), notPrimary.Shard)
+
+	_, err = setup.nodes["n3"].Increment(setup.ctx, &proto.IncrementRequest{Key: "abc", Delta: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assertOnAllNodes(t, setup, "abc", "", false)
+
+	// nor do they accept backup writes from anyone but the primary
+	_, err = setup.nodes["n3"].BackupWrite(setup.ctx, &proto.BackupWriteRequest{
+		Primary: "n2",
+		Key:     []byte("abc"),
+		Value:   &proto.GetShardValue{Key: []byte("abc"), Value: []byte("123"), NoExpiry: true, Version: 1},
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	setup.Shutdown()
+}
+
+func TestPrimaryBackupClientFollowsRedirect(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+
+	// a client whose ShardMap is out of date still lands on the primary
+	stale := copyShardMapState(setup.shardMap.GetState())
+	stale.ShardsToNodes[1] = []string{"n3", "n1", "n2"}
+	staleMap := &kv.ShardMap{}
+	staleMap.Update(&stale)
+	client := kv.MakeKvWithOptions(staleMap, &setup.clientPool, kv.KvOptions{PrimaryBackup: true})
+
+	assert.Nil(t, client.Set(setup.ctx, "abc", "123", 10*time.Second))
+	assertOnAllNodes(t, setup, "abc", "123", true)
+	assert.Equal(t, int64(1), client.Stats()["primary_redirects"])
+	assert.Equal(t, int64(1), setup.nodes["n3"].Stats()["primary_redirects"])
+
+	setup.Shutdown()
+}
+
+func TestPrimaryBackupFailsWithoutBackup(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+	setup.clientPool.OverrideRpcError("n3", status.Errorf(codes.Unavailable, "down"))
+
+	err := setup.Set("abc", "123", 10*time.Second)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["backup_write_failures"])
+
+	setup.clientPool.ClearRpcOverrides("n3")
+	assert.Nil(t, setup.Set("abc", "456", 10*time.Second))
+	assertOnAllNodes(t, setup, "abc", "456", true)
+
+	setup.Shutdown()
+}
+
+func TestPrimaryBackupHandoff(t *testing.T) {
+	setup := makePrimaryBackupSetup(MakeThreeNodesAllAssignedSingleShard())
+	assert.Nil(t, setup.Set("abc", "0", 10*time.Second))
+
+	// keep incrementing while the primary moves from n1 to n2 and back
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := setup.kv.Increment(setup.ctx, "abc", 1, 10*time.Second); err == nil {
+					mutex.Lock()
+					succeeded++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, primary := range []string{"n2", "n1", "n2"} {
+		time.Sleep(50 * time.Millisecond)
+		setup.UpdateShardMapping(map[int][]string{1: append([]string{primary}, nodesExcept([]string{"n1", "n2", "n3"}, primary)...)})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(done)
+	wg.Wait()
+
+	// the new primary took over
+	err := setup.NodeSet("n1", "abc", "0", 10*time.Second)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	value, err := setup.kv.Increment(setup.ctx, "abc", 1, 10*time.Second)
+	assert.Nil(t, err)
+
+	// no acked increment was lost, and the replicas agree
+	assert.GreaterOrEqual(t, value, int64(succeeded+1))
+	assertOnAllNodes(t, setup, "abc", strconv.FormatInt(value, 10), true)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,290 @@
+package kvtest
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for Raft-replicated shards.
+
+func makeRaftSetup(t *testing.T, shardMap kv.ShardMapState) *TestSetup {
+	baseDir := t.TempDir()
+	setup := MakeTestSetupWithOptions(shardMap, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{Raft: true, DataDir: filepath.Join(baseDir, nodeName)}
+	})
+	setup.kv = kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{Raft: true})
+	return setup
+}
+
+/*
+ * Waits for exactly one of `nodes` to serve reads as the leader of the shard
+ * of "probe", and returns it.
+ */
+func findRaftLeader(t *testing.T, setup *TestSetup, nodes []string) string {
+	leader := ""
+	assert.Eventually(t, func() bool {
+		leaders := make([]string, 0)
+		for _, node := range nodes {
+			ctx, cancel := context.WithTimeout(setup.ctx, 100*time.Millisecond)
+			_, err := setup.nodes[node].Get(ctx, &proto.GetRequest{Key: "probe"})
+			cancel()
+			if err == nil {
+				leaders = append(leaders, node)
+			}
+		}
+		if len(leaders) != 1 {
+			return false
+		}
+		leader = leaders[0]
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+	return leader
+}
+
+// Value of `key` in the local copy of shard 1 on `node`, whatever its role.
+// Tombstones count as not found.
+func localValue(setup *TestSetup, node string, key string) (string, bool) {
+	response, err := setup.nodes[node].GetShardContents(setup.ctx, &proto.GetShardContentsRequest{Shard: 1})
+	if err != nil {
+		return "", false
+	}
+	for _, value := range response.Values {
+		if string(value.Key) == key && !value.Deleted {
+			return string(value.Value), true
+		}
+	}
+	return "", false
+}
+
+func TestRaftServesClients(t *testing.T) {
+	setup := makeRaftSetup(t, MakeThreeNodesAllAssignedSingleShard())
+	findRaftLeader(t, setup, []string{"n1", "n2", "n3"})
+
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	val, wasFound, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+
+	swapped, version, err := setup.kv.CompareAndSet(setup.ctx, "abc", "456", 10*time.Second, 1)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(2), version)
+	swapped, _, err = setup.kv.CompareAndSet(setup.ctx, "abc", "789", 10*time.Second, 1)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	ttl, wasFound, err := setup.kv.GetTTL(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Greater(t, ttl, 9*time.Second)
+
+	assert.Nil(t, setup.Delete("abc"))
+	_, wasFound, err = setup.Get("abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	// every replica applies the committed writes
+	assert.Nil(t, setup.Set("abc", "final", 10*time.Second))
+	assert.Eventually(t, func() bool {
+		for _, node := range []string{"n1", "n2", "n3"} {
+			if val, found := localValue(setup, node, "abc"); !found || val != "final" {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 20*time.Millisecond)
+
+	setup.Shutdown()
+}
+
+func TestRaftConcurrentIncrements(t *testing.T) {
+	setup := makeRaftSetup(t, MakeThreeNodesAllAssignedSingleShard())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := setup.kv.Increment(setup.ctx, "counter", 1, 10*time.Second)
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, wasFound, err := setup.Get("counter")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "100", val)
+
+	setup.Shutdown()
+}
+
+func TestRaftFollowersRedirect(t *testing.T) {
+	setup := makeRaftSetup(t, MakeThreeNodesAllAssignedSingleShard())
+	leader := findRaftLeader(t, setup, []string{"n1", "n2", "n3"})
+	follower := nodesExcept([]string{"n1", "n2", "n3"}, leader)[0]
+
+	// once the follower heard from the leader, it names it
+	assert.Eventually(t, func() bool {
+		err := setup.NodeSet(follower, "abc", "123", 10*time.Second)
+		if status.Code(err) != codes.FailedPrecondition {
+			return false
+		}
+		details := status.Convert(err).Details()
+		if len(details) != 1 {
+			return false
+		}
+		notPrimary, ok := details[0].(*proto.NotPrimary)
+		return ok && notPrimary.Primary == leader
+	}, time.Second, 20*time.Millisecond)
+
+	// followers do not serve reads either, which could be stale
+	_, _, err := setup.NodeGet(follower, "abc")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// a client which starts at a follower gets redirected
+	assert.Nil(t, setup.NodeSet(leader, "abc", "123", 10*time.Second))
+	stale := copyShardMapState(setup.shardMap.GetState())
+	stale.ShardsToNodes[1] = append([]string{follower}, nodesExcept([]string{"n1", "n2", "n3"}, follower)...)
+	staleMap := &kv.ShardMap{}
+	staleMap.Update(&stale)
+	client := kv.MakeKvWithOptions(staleMap, &setup.clientPool, kv.KvOptions{Raft: true})
+	val, wasFound, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+	assert.Equal(t, int64(1), client.Stats()["primary_redirects"])
+
+	setup.Shutdown()
+}
+
+func TestRaftLeaderPartitioned(t *testing.T) {
+	setup := makeRaftSetup(t, MakeThreeNodesAllAssignedSingleShard())
+	nodes := []string{"n1", "n2", "n3"}
+	oldLeader := findRaftLeader(t, setup, nodes)
+	assert.Nil(t, setup.Set("abc", "before", 10*time.Second))
+
+	majority := nodesExcept(nodes, oldLeader)
+	setup.clientPool.Partition([]string{oldLeader}, majority)
+
+	// the old leader cannot commit on its own
+	ctx, cancel := context.WithTimeout(setup.ctx, 500*time.Millisecond)
+	_, err := setup.nodes[oldLeader].Set(ctx, &proto.SetRequest{Key: "abc", Value: "lost", TtlMs: 10000})
+	cancel()
+	assert.NotNil(t, err)
+
+	// the majority elects a new leader, which the client finds
+	newLeader := findRaftLeader(t, setup, majority)
+	assert.NotEqual(t, oldLeader, newLeader)
+	val, wasFound, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "before", val)
+	assert.Nil(t, setup.Set("abc", "after", 10*time.Second))
+
+	// the old leader stepped down rather than serve a stale read
+	_, _, err = setup.NodeGet(oldLeader, "abc")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// once healed, it catches up and drops the write it could not commit
+	setup.clientPool.HealPartition()
+	assert.Eventually(t, func() bool {
+		val, found := localValue(setup, oldLeader, "abc")
+		return found && val == "after"
+	}, 5*time.Second, 20*time.Millisecond)
+	val, _, err = setup.Get("abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "after", val)
+
+	setup.Shutdown()
+}
+
+func TestRaftMembershipFollowsShardMap(t *testing.T) {
+	setup := makeRaftSetup(t, kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(4),
+		ShardsToNodes: map[int][]string{1: {"n1", "n2", "n3"}},
+	})
+	for i := 0; i < 20; i++ {
+		assert.Nil(t, setup.Set("key-"+strconv.Itoa(i), strconv.Itoa(i), 10*time.Second))
+	}
+
+	setup.UpdateShardMapping(map[int][]string{1: {"n2", "n3", "n4"}})
+
+	// n4 gets the whole log, and n1 leaves the group
+	assert.Eventually(t, func() bool {
+		for i := 0; i < 20; i++ {
+			if val, found := localValue(setup, "n4", "key-"+strconv.Itoa(i)); !found || val != strconv.Itoa(i) {
+				return false
+			}
+		}
+		_, found := localValue(setup, "n1", "key-0")
+		return !found
+	}, 8*time.Second, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, totalStat(setup, "raft_config_changes"), int64(2))
+
+	// the new group serves clients, and keeps working without n1
+	setup.clientPool.OverrideGetClientError("n1", status.Error(codes.Unavailable, "down"))
+	assert.Nil(t, setup.Set("key-0", "updated", 10*time.Second))
+	val, wasFound, err := setup.Get("key-0")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "updated", val)
+
+	setup.Shutdown()
+}
+
+func TestRaftRejectsIncompatibleOptions(t *testing.T) {
+	shardMap := &kv.ShardMap{}
+	state := MakeBasicOneShard()
+	shardMap.Update(&state)
+	dataDir := t.TempDir()
+	_, err := kv.MakeKvServerWithOptions("n1", shardMap, &TestClientPool{}, kv.KvServerOptions{Raft: true, DataDir: dataDir, PrimaryBackup: true})
+	assert.NotNil(t, err)
+	_, err = kv.MakeKvServerWithOptions("n1", shardMap, &TestClientPool{}, kv.KvServerOptions{Raft: true, DataDir: dataDir, MemoryBudgetBytes: 1 << 20})
+	assert.NotNil(t, err)
+	// the groups' state would be lost on restart
+	_, err = kv.MakeKvServerWithOptions("n1", shardMap, &TestClientPool{}, kv.KvServerOptions{Raft: true})
+	assert.NotNil(t, err)
+}
+
+func TestRaftCommittedWriteSurvivesRestart(t *testing.T) {
+	setup := makeRaftSetup(t, MakeThreeNodesAllAssignedSingleShard())
+	nodes := []string{"n1", "n2", "n3"}
+	leader := findRaftLeader(t, setup, nodes)
+	followers := nodesExcept(nodes, leader)
+	restarted, lagging := followers[0], followers[1]
+
+	// committed by the leader and `restarted` alone
+	setup.clientPool.Partition([]string{lagging}, []string{leader, restarted})
+	assert.Nil(t, setup.Set("abc", "committed", 10*time.Second))
+	assert.Eventually(t, func() bool {
+		val, found := localValue(setup, restarted, "abc")
+		return found && val == "committed"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// the new leader has to be elected by the two nodes of which only the
+	// restarted one has the write
+	assert.Nil(t, setup.RestartNode(restarted))
+	setup.clientPool.Partition([]string{leader}, []string{restarted, lagging})
+	findRaftLeader(t, setup, []string{restarted, lagging})
+	val, wasFound, err := setup.Get("abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "committed", val)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,155 @@
+package kvtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for read repair and the ReplicaWrite RPC it uses.
+
+func TestReadRepairFixesStaleReplica(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	makeStaleReplica(t, setup)
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		ReadConsistency: kv.ConsistencyAll,
+		ReadRepair:      true,
+	})
+
+	val, wasFound, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "new", val)
+
+	assert.Eventually(t, func() bool {
+		val, _, err := setup.NodeGet("n1", "abc")
+		return err == nil && val == "new"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), client.Stats()["read_repairs"])
+
+	// the repaired value keeps its expiry
+	ttl := nodeGetTTL(t, setup, "n1", "abc")
+	assert.True(t, ttl.WasFound)
+	assert.Greater(t, ttl.TtlMsRemaining, int64(8000))
+	assert.LessOrEqual(t, ttl.TtlMsRemaining, int64(10000))
+
+	// replicas agree now, so there is nothing left to repair
+	_, _, err = client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(1), client.Stats()["read_repairs"])
+
+	setup.Shutdown()
+}
+
+func TestReadRepairFixesMissingKey(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		ReadConsistency:      kv.ConsistencyAll,
+		WriteConsistency:     kv.ConsistencyQuorum,
+		ReadRepair:           true,
+		DisableHintedHandoff: true,
+	})
+	whileDown(t, setup, "n1", func() {
+		assert.Nil(t, client.SetBytes(setup.ctx, []byte("abc"), []byte{0xff, 0}, 10*time.Second))
+	})
+
+	val, wasFound, err := client.GetBytes(setup.ctx, []byte("abc"))
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, []byte{0xff, 0}, val)
+
+	assert.Eventually(t, func() bool {
+		response, err := setup.nodes["n1"].GetBytes(context.Background(), &proto.GetBytesRequest{Key: []byte("abc")})
+		return err == nil && response.WasFound
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), client.Stats()["read_repairs"])
+
+	setup.Shutdown()
+}
+
+func TestReadRepairFixesMissedDelete(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{
+		ReadConsistency:      kv.ConsistencyAll,
+		WriteConsistency:     kv.ConsistencyQuorum,
+		ReadRepair:           true,
+		DisableHintedHandoff: true,
+	})
+	assert.Nil(t, client.Set(setup.ctx, "abc", "123", 10*time.Second))
+	whileDown(t, setup, "n1", func() {
+		assert.Nil(t, client.Delete(setup.ctx, "abc"))
+	})
+	val, _, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "123", val)
+
+	_, wasFound, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	// n1 gets the tombstone, with the version the key had when it was deleted
+	assert.Eventually(t, func() bool {
+		tombstone := shardContents(t, setup, "n1")["abc"]
+		return tombstone != nil && tombstone.Deleted
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), shardContents(t, setup, "n1")["abc"].Version)
+	assert.Equal(t, int64(1), client.Stats()["read_repairs"])
+
+	setup.Shutdown()
+}
+
+func TestReadRepairDisabledByDefault(t *testing.T) {
+	setup := MakeTestSetup(MakeThreeNodesAllAssignedSingleShard())
+	makeStaleReplica(t, setup)
+
+	all := kv.WithConsistency(setup.ctx, kv.ConsistencyAll)
+	for i := 0; i < 3; i++ {
+		val, _, err := setup.kv.Get(all, "abc")
+		assert.Nil(t, err)
+		assert.Equal(t, "new", val)
+	}
+	time.Sleep(100 * time.Millisecond)
+	val, _, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "old", val)
+	assert.Empty(t, setup.kv.Stats())
+
+	setup.Shutdown()
+}
+
+func TestServerReplicaWriteOnlyAppliesNewer(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	assert.Nil(t, setup.NodeSet("n1", "abc", "current", 10*time.Second))
+	response, err := setup.nodes["n1"].Get(context.Background(), &proto.GetRequest{Key: "abc"})
+	assert.Nil(t, err)
+
+	replicaWrite := func(value string, timestamp uint64) bool {
+		response, err := setup.nodes["n1"].ReplicaWrite(context.Background(), &proto.ReplicaWriteRequest{
+			Value: &proto.GetShardValue{Key: []byte("abc"), Value: []byte(value), TtlMsRemaining: 10000, Version: 7, Timestamp: timestamp},
+		})
+		assert.Nil(t, err)
+		return response.Applied
+	}
+	assert.False(t, replicaWrite("older", response.Timestamp-1))
+	assert.True(t, replicaWrite("newer", response.Timestamp+1))
+
+	val, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "newer", val)
+	assert.Equal(t, uint64(7), version)
+
+	_, err = setup.nodes["n1"].ReplicaWrite(context.Background(), &proto.ReplicaWriteRequest{
+		Value: &proto.GetShardValue{Key: []byte("")},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	setup.Shutdown()
+}
@@ -0,0 +1,212 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests for writes to a shard while it is being copied in.
+
+/*
+ * Adds n2 as a replica of shard 1, hosted by n1 alone, with the copy slowed
+ * down. Returns once n2 accepts writes to the shard, and a channel closed once
+ * the copy is done.
+ */
+func startSlowShardCopy(t *testing.T, setup *TestSetup) chan struct{} {
+	setup.clientPool.AddLatencyInjection("n1", 500*time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}})
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return setup.NodeSet("n2", "probe", "", 10*time.Second) == nil
+	}, time.Second, 5*time.Millisecond)
+	return done
+}
+
+func TestReceivingShardMergesWritesWithCopy(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	})
+	now := uint64(time.Now().UnixNano())
+	nodeSetAt(t, setup, "n1", "overwritten", "old", now)
+	nodeSetAt(t, setup, "n1", "deleted", "old", now)
+	nodeSetAt(t, setup, "n1", "newer-on-source", "source", now+10)
+	nodeSetAt(t, setup, "n1", "untouched", "copied", now)
+
+	done := startSlowShardCopy(t, setup)
+	nodeSetAt(t, setup, "n2", "overwritten", "new", now+1)
+	nodeDeleteAt(t, setup, "n2", "deleted", now+1)
+	nodeSetAt(t, setup, "n2", "newer-on-source", "stale", now+1)
+
+	// reads wait for the copy
+	_, _, err := setup.NodeGet("n2", "untouched")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = setup.NodeIncrement("n2", "counter", 1, 10*time.Second)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	<-done
+	expected := map[string]string{"overwritten": "new", "newer-on-source": "source", "untouched": "copied"}
+	for key, value := range expected {
+		val, wasFound, err := setup.NodeGet("n2", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound, key)
+		assert.Equal(t, value, val, key)
+	}
+	_, wasFound, err := setup.NodeGet("n2", "deleted")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+	assert.Equal(t, int64(2), setup.nodes["n2"].Stats()["shard_copy_keys_superseded"])
+
+	setup.Shutdown()
+}
+
+func TestReceivingShardInvisibleToClients(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	})
+	assert.Nil(t, setup.Set("before", "1", 10*time.Second))
+
+	done := startSlowShardCopy(t, setup)
+	// writes reach both replicas, and reads are served by n1 meanwhile
+	assert.Nil(t, setup.Set("during", "2", 10*time.Second))
+	for _, key := range []string{"before", "during"} {
+		_, wasFound, err := setup.Get(key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound, key)
+	}
+
+	<-done
+	for key, value := range map[string]string{"before": "1", "during": "2"} {
+		val, wasFound, err := setup.NodeGet("n2", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound, key)
+		assert.Equal(t, value, val, key)
+	}
+
+	setup.Shutdown()
+}
+
+func TestReceivingShardKeepsVersionsInStep(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	})
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, setup.Set("abc", "old", 10*time.Second))
+	}
+	assert.Nil(t, setup.Set("deleted", "old", 10*time.Second))
+
+	done := startSlowShardCopy(t, setup)
+	// reaches both nodes; n2 does not have the keys yet
+	assert.Nil(t, setup.Set("abc", "new", 10*time.Second))
+	assert.Nil(t, setup.Delete("deleted"))
+	<-done
+
+	for key, version := range map[string]uint64{"abc": 6, "deleted": 1} {
+		for _, node := range []string{"n1", "n2"} {
+			assert.Equal(t, version, shardContents(t, setup, node)[key].Version, key+" on "+node)
+		}
+	}
+	_, version, wasFound, err := setup.kv.GetWithVersion(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	swapped, newVersion, err := setup.kv.CompareAndSet(setup.ctx, "abc", "swapped", 10*time.Second, version)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	assert.Equal(t, uint64(7), newVersion)
+
+	setup.Shutdown()
+}
+
+func makeShardCopySetup(parallelism int, timeout time.Duration) *TestSetup {
+	shardsToNodes := make(map[int][]string)
+	for shard := 1; shard <= 4; shard++ {
+		shardsToNodes[shard] = []string{"n1"}
+	}
+	return MakeTestSetupWithOptions(kv.ShardMapState{
+		NumShards:     4,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: shardsToNodes,
+	}, func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{ShardCopyParallelism: parallelism, ShardCopyTimeout: timeout}
+	})
+}
+
+func TestShardCopiesRunInParallel(t *testing.T) {
+	setup := makeShardCopySetup(4, 0)
+	keys := RandomKeys(50, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set(key, "value", 10*time.Second))
+	}
+
+	setup.clientPool.AddLatencyInjection("n1", 500*time.Millisecond)
+	start := time.Now()
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}, 2: {"n1", "n2"}, 3: {"n1", "n2"}, 4: {"n1", "n2"}})
+	// one at a time, the four copies would take 2s
+	assert.Less(t, time.Since(start), 1500*time.Millisecond)
+	assert.Equal(t, int64(4), setup.nodes["n2"].Stats()["shards_copied"])
+
+	for _, key := range keys {
+		val, wasFound, err := setup.NodeGet("n2", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound, key)
+		assert.Equal(t, "value", val, key)
+	}
+
+	setup.Shutdown()
+}
+
+func TestShardServedOnceItsCopyIsDone(t *testing.T) {
+	setup := makeShardCopySetup(1, 0)
+	setup.clientPool.AddLatencyInjection("n1", 300*time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}, 2: {"n1", "n2"}, 3: {"n1", "n2"}, 4: {"n1", "n2"}})
+		close(done)
+	}()
+
+	// the first shard is served while the others are still being copied
+	assert.Eventually(t, func() bool {
+		return setup.nodes["n2"].Stats()["shards_copied"] >= 1
+	}, 2*time.Second, 5*time.Millisecond)
+	served := 0
+	for shard := 1; shard <= 4; shard++ {
+		_, err := setup.nodes["n2"].GetShardContents(setup.ctx, &proto.GetShardContentsRequest{Shard: int32(shard)})
+		if err == nil {
+			served++
+		}
+	}
+	assert.GreaterOrEqual(t, served, 1)
+	assert.Less(t, served, 4)
+
+	<-done
+	assert.Equal(t, int64(4), setup.nodes["n2"].Stats()["shards_copied"])
+	setup.Shutdown()
+}
+
+func TestShardCopyTimesOut(t *testing.T) {
+	setup := makeShardCopySetup(0, 100*time.Millisecond)
+	setup.clientPool.AddLatencyInjection("n1", 500*time.Millisecond)
+	setup.UpdateShardMapping(map[int][]string{1: {"n1", "n2"}, 2: {"n1"}, 3: {"n1"}, 4: {"n1"}})
+	assert.Equal(t, int64(1), setup.nodes["n2"].Stats()["shard_copy_failures"])
+	assert.Equal(t, int64(0), setup.nodes["n2"].Stats()["shards_copied"])
+
+	// the shard is served anyway, to be filled in by writes and anti-entropy
+	_, err := setup.nodes["n2"].GetShardContents(setup.ctx, &proto.GetShardContentsRequest{Shard: 1})
+	assert.Nil(t, err)
+
+	setup.Shutdown()
+}
@@ -0,0 +1,156 @@
+package kvtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for Scan (server) and Kv.Scan / ScanIterator (client).
+
+func TestServerScan(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	server := setup.nodes["n1"]
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, setup.NodeSet("n1", fmt.Sprintf("a%d", i), "value", 10*time.Second))
+	}
+	assert.Nil(t, setup.NodeSet("n1", "b0", "value", 10*time.Second))
+	assert.Nil(t, setup.NodeSet("n1", "a-expired", "value", 50*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+
+	response, err := server.Scan(context.Background(), &proto.ScanRequest{Shard: 1, Prefix: []byte("a"), Limit: 4})
+	assert.Nil(t, err)
+	assert.True(t, response.More)
+	assert.Equal(t, 4, len(response.Values))
+	for i, value := range response.Values {
+		assert.Equal(t, fmt.Sprintf("a%d", i), string(value.Key))
+		assert.Equal(t, "value", string(value.Value))
+	}
+
+	response, err = server.Scan(context.Background(), &proto.ScanRequest{Shard: 1, Prefix: []byte("a"), StartAfter: []byte("a7"), Limit: 4})
+	assert.Nil(t, err)
+	assert.False(t, response.More)
+	assert.Equal(t, 2, len(response.Values))
+	assert.Equal(t, "a8", string(response.Values[0].Key))
+	assert.Equal(t, "a9", string(response.Values[1].Key))
+
+	// no prefix: everything except the expired key
+	response, err = server.Scan(context.Background(), &proto.ScanRequest{Shard: 1})
+	assert.Nil(t, err)
+	assert.False(t, response.More)
+	assert.Equal(t, 11, len(response.Values))
+
+	_, err = server.Scan(context.Background(), &proto.ScanRequest{Shard: 2})
+	assertErrorWithCode(t, err, codes.NotFound)
+
+	setup.Shutdown()
+}
+
+func TestClientScanAllShards(t *testing.T) {
+	setup := MakeTestSetup(MakeFourNodesWithFiveShards())
+
+	keys := RandomKeys(300, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set("p:"+key, key, 10*time.Second))
+		assert.Nil(t, setup.Set("q:"+key, key, 10*time.Second))
+	}
+
+	seen := make(map[string]int)
+	cursor := ""
+	pages := 0
+	for {
+		entries, next, err := setup.kv.Scan(setup.ctx, "p:", cursor, 7)
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, len(entries), 7)
+		for _, entry := range entries {
+			assert.Equal(t, "p:"+entry.Value, entry.Key)
+			seen[entry.Key]++
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.GreaterOrEqual(t, pages, 300/7)
+	assert.Equal(t, len(keys), len(seen))
+	for _, key := range keys {
+		assert.Equal(t, 1, seen["p:"+key])
+	}
+
+	_, _, err := setup.kv.Scan(setup.ctx, "p:", "not a cursor", 7)
+	assert.NotNil(t, err)
+
+	setup.Shutdown()
+}
+
+func TestClientScanIteratorResumes(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	keys := RandomKeys(50, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set(key, "value", 10*time.Second))
+	}
+
+	// one replica failing is not visible to the caller
+	setup.clientPool.OverrideRpcError("n1", errors.New("oh no!"))
+	it := setup.kv.NewScanIterator(setup.ctx, "", "", 10)
+	seen := make(map[string]bool)
+	for i := 0; i < 25; i++ {
+		assert.True(t, it.Next())
+		seen[it.Entry().Key] = true
+	}
+	cursor := it.Cursor()
+
+	// with both replicas down the iterator stops with an error...
+	setup.clientPool.OverrideRpcError("n2", errors.New("oh no!"))
+	it = setup.kv.NewScanIterator(setup.ctx, "", cursor, 10)
+	assert.False(t, it.Next())
+	assert.NotNil(t, it.Err())
+	assert.Equal(t, cursor, it.Cursor())
+
+	// ...and can be resumed from where the first one left off
+	setup.clientPool.ClearRpcOverrides("n2")
+	it = setup.kv.NewScanIterator(setup.ctx, "", it.Cursor(), 10)
+	for it.Next() {
+		assert.False(t, seen[it.Entry().Key])
+		seen[it.Entry().Key] = true
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, "", it.Cursor())
+	assert.Equal(t, len(keys), len(seen))
+
+	setup.Shutdown()
+}
+
+func TestClientScanBulkInvalidate(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeMultiShard())
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, setup.Set(fmt.Sprintf("session:%d", i), "value", 10*time.Second))
+		assert.Nil(t, setup.Set(fmt.Sprintf("user:%d", i), "value", 10*time.Second))
+	}
+
+	it := setup.kv.NewScanIterator(setup.ctx, "session:", "", 0)
+	toDelete := make([]string, 0)
+	for it.Next() {
+		toDelete = append(toDelete, it.Entry().Key)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 100, len(toDelete))
+	assert.Empty(t, setup.kv.MultiDelete(setup.ctx, toDelete))
+
+	entries, cursor, err := setup.kv.Scan(setup.ctx, "", "", 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, 100, len(entries))
+
+	setup.Shutdown()
+}
@@ -835,7 +835,8 @@ func TestServerRestartShardCopy(t *testing.T) {
 	assert.Equal(t, "123", val)
 
 	setup.nodes["n2"].Shutdown()
-	setup.nodes["n2"] = kv.MakeKvServer("n2", setup.shardMap, &setup.clientPool)
+	setup.nodes["n2"], err = kv.MakeKvServerWithOptions("n2", setup.shardMap, &setup.clientPool, setup.nodeOptions("n2"))
+	assert.Nil(t, err)
 
 	// n2 should copy the data from n1 on restart
 	val, wasFound, err = setup.NodeGet("n2", "abc")
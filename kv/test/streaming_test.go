@@ -0,0 +1,168 @@
+package kvtest
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// Tests for streaming shard transfers (StreamShardContents).
+
+func streamShard(t *testing.T, server *kv.KvServerImpl, shard int, maxChunkBytes int) ([]*proto.GetShardContentsResponse, error) {
+	stream := startTestStream(context.Background(), func(stream *testStream[proto.GetShardContentsResponse]) error {
+		return server.StreamShardContents(
+			&proto.StreamShardContentsRequest{Shard: int32(shard), MaxChunkBytes: int32(maxChunkBytes)},
+			stream,
+		)
+	})
+	chunks := make([]*proto.GetShardContentsResponse, 0)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestStreamShardContentsChunked(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	keys := RandomKeys(500, 10)
+	value := strings.Repeat("x", 200)
+	for _, key := range keys {
+		assert.Nil(t, setup.NodeSet("n1", key, value, 100*time.Second))
+	}
+
+	maxChunkBytes := 4096
+	chunks, err := streamShard(t, setup.nodes["n1"], 1, maxChunkBytes)
+	assert.Nil(t, err)
+	assert.Greater(t, len(chunks), 1)
+
+	seen := make(map[string]bool)
+	for _, chunk := range chunks {
+		// a chunk may overshoot the limit by at most one entry
+		last := chunk.Values[len(chunk.Values)-1]
+		assert.LessOrEqual(t, gproto.Size(chunk)-gproto.Size(last), maxChunkBytes)
+		for _, v := range chunk.Values {
+			assert.Equal(t, value, string(v.Value))
+			assert.Greater(t, v.TtlMsRemaining, int64(0))
+			seen[string(v.Key)] = true
+		}
+	}
+	for _, key := range keys {
+		assert.True(t, seen[key])
+	}
+
+	setup.Shutdown()
+}
+
+func TestStreamShardContentsIsPointInTime(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	keys := RandomKeys(300, 10)
+	value := strings.Repeat("x", 200)
+	for _, key := range keys {
+		assert.Nil(t, setup.NodeSet("n1", key, value, 100*time.Second))
+	}
+
+	server := setup.nodes["n1"]
+	stream := startTestStream(context.Background(), func(stream *testStream[proto.GetShardContentsResponse]) error {
+		return server.StreamShardContents(&proto.StreamShardContentsRequest{Shard: 1, MaxChunkBytes: 4096}, stream)
+	})
+	first, err := stream.Recv()
+	assert.Nil(t, err)
+
+	// the server is now blocked sending the second chunk, which must not stop
+	// writes to the shard, nor let them leak into the rest of the transfer
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i, key := range keys {
+			if i%2 == 0 {
+				assert.Nil(t, setup.NodeDelete("n1", key))
+			} else {
+				assert.Nil(t, setup.NodeSet("n1", key, "new", 100*time.Second))
+			}
+			assert.Nil(t, setup.NodeSet("n1", key+"-added", "new", 100*time.Second))
+		}
+	}()
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writes blocked by the shard transfer")
+	}
+
+	seen := make(map[string]int)
+	for chunk, err := first, error(nil); err != io.EOF; chunk, err = stream.Recv() {
+		assert.Nil(t, err)
+		for _, v := range chunk.Values {
+			assert.Equal(t, value, string(v.Value))
+			seen[string(v.Key)]++
+		}
+	}
+	assert.Equal(t, len(keys), len(seen))
+	for _, key := range keys {
+		assert.Equal(t, 1, seen[key])
+	}
+
+	setup.Shutdown()
+}
+
+func TestStreamShardContentsEmptyShard(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	chunks, err := streamShard(t, setup.nodes["n1"], 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(chunks))
+	assert.Equal(t, 0, len(chunks[0].Values))
+
+	setup.Shutdown()
+}
+
+func TestStreamShardContentsNotHosted(t *testing.T) {
+	setup := MakeTestSetup(MakeSingleNodeHalfShardsAssigned())
+
+	_, err := streamShard(t, setup.nodes["n1"], 8, 0)
+	assertErrorWithCode(t, err, codes.NotFound)
+
+	setup.Shutdown()
+}
+
+func TestShardMigrationFallsBackToUnaryCopy(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards: 1,
+		Nodes:     makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{
+			1: {"n1"},
+		},
+	})
+
+	keys := RandomKeys(100, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.NodeSet("n1", key, key+"-value", 100*time.Second))
+	}
+
+	setup.clientPool.DisableStreaming("n1")
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+
+	for _, key := range keys {
+		val, wasFound, err := setup.NodeGet("n2", key)
+		assert.Nil(t, err)
+		assert.True(t, wasFound)
+		assert.Equal(t, key+"-value", val)
+	}
+
+	setup.Shutdown()
+}
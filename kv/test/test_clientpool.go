@@ -2,6 +2,7 @@ package kvtest
 
 import (
 	"context"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,15 +11,23 @@ import (
 	"cs426.yale.edu/lab4/kv/proto"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type TestClientPool struct {
 	mutex           sync.RWMutex
 	getClientErrors map[string]error
 	nodes           map[string]*TestClient
+	// side of the network partition each node is on (see Partition)
+	partitions map[string]int
 }
 
 func (cp *TestClientPool) Setup(nodes map[string]*kv.KvServerImpl) {
+	// servers may already be calling each other (e.g. anti-entropy)
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
 	cp.nodes = make(map[string]*TestClient)
 	for nodeName, server := range nodes {
 		cp.nodes[nodeName] = &TestClient{
@@ -42,6 +51,56 @@ func (cp *TestClientPool) GetClient(nodeName string) (proto.KvClient, error) {
 	return cp.nodes[nodeName], nil
 }
 
+/*
+ * The pool as seen by `nodeName`: like the TestClientPool, but nodes on the
+ * other side of a network partition (see Partition) are unreachable.
+ */
+func (cp *TestClientPool) ForNode(nodeName string) kv.ClientPool {
+	return &nodeClientPool{pool: cp, from: nodeName}
+}
+
+type nodeClientPool struct {
+	pool *TestClientPool
+	from string
+}
+
+func (ncp *nodeClientPool) GetClient(nodeName string) (proto.KvClient, error) {
+	if !ncp.pool.reachable(ncp.from, nodeName) {
+		return nil, status.Errorf(codes.Unavailable, "%s cannot reach %s: network partition", ncp.from, nodeName)
+	}
+	return ncp.pool.GetClient(nodeName)
+}
+
+/*
+ * Splits the nodes into `groups` which cannot reach each other through the pools
+ * returned by ForNode, until HealPartition. Nodes in no group (e.g. the test's own
+ * client) still reach every node.
+ */
+func (cp *TestClientPool) Partition(groups ...[]string) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	cp.partitions = make(map[string]int)
+	for i, group := range groups {
+		for _, node := range group {
+			cp.partitions[node] = i
+		}
+	}
+}
+
+func (cp *TestClientPool) HealPartition() {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	cp.partitions = nil
+}
+
+func (cp *TestClientPool) reachable(from string, to string) bool {
+	cp.mutex.RLock()
+	defer cp.mutex.RUnlock()
+	fromGroup, fromPartitioned := cp.partitions[from]
+	toGroup, toPartitioned := cp.partitions[to]
+	return !fromPartitioned || !toPartitioned || fromGroup == toGroup
+}
+
 func (cp *TestClientPool) OverrideGetClientError(nodeName string, err error) {
 	cp.mutex.Lock()
 	defer cp.mutex.Unlock()
@@ -80,6 +139,11 @@ func (cp *TestClientPool) OverrideGetShardContentsResponse(nodeName string, resp
 	defer cp.mutex.RUnlock()
 	cp.nodes[nodeName].OverrideGetShardContentsResponse(response)
 }
+func (cp *TestClientPool) DisableStreaming(nodeName string) {
+	cp.mutex.RLock()
+	defer cp.mutex.RUnlock()
+	cp.nodes[nodeName].DisableStreaming()
+}
 func (cp *TestClientPool) AddLatencyInjection(nodeName string, duration time.Duration) {
 	cp.mutex.RLock()
 	defer cp.mutex.RUnlock()
@@ -103,6 +167,12 @@ func (cp *TestClientPool) ClearRequestsSent(nodeName string) {
 	atomic.StoreUint64(&client.requestsSent, 0)
 }
 
+func (cp *TestClientPool) ReplaceServer(nodeName string, server *kv.KvServerImpl) {
+	cp.mutex.Lock()
+	defer cp.mutex.Unlock()
+	cp.nodes[nodeName].server = server
+}
+
 func (cp *TestClientPool) ClearServerImpls() {
 	cp.mutex.Lock()
 	defer cp.mutex.Unlock()
@@ -125,6 +195,7 @@ type TestClient struct {
 	deleteResponse           *proto.DeleteResponse
 	getShardContentsResponse *proto.GetShardContentsResponse
 	latencyInjection         *time.Duration
+	disableStreaming         bool
 }
 
 func (c *TestClient) Get(ctx context.Context, req *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
@@ -172,6 +243,150 @@ func (c *TestClient) Delete(ctx context.Context, req *proto.DeleteRequest, opts
 	}
 	return c.server.Delete(ctx, req)
 }
+func (c *TestClient) GetBytes(ctx context.Context, req *proto.GetBytesRequest, opts ...grpc.CallOption) (*proto.GetBytesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.GetBytes(ctx, req)
+}
+func (c *TestClient) SetBytes(ctx context.Context, req *proto.SetBytesRequest, opts ...grpc.CallOption) (*proto.SetBytesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.SetBytes(ctx, req)
+}
+func (c *TestClient) DeleteBytes(ctx context.Context, req *proto.DeleteBytesRequest, opts ...grpc.CallOption) (*proto.DeleteBytesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.DeleteBytes(ctx, req)
+}
+func (c *TestClient) CompareAndSet(ctx context.Context, req *proto.CompareAndSetRequest, opts ...grpc.CallOption) (*proto.CompareAndSetResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.CompareAndSet(ctx, req)
+}
+func (c *TestClient) GetTTL(ctx context.Context, req *proto.GetTTLRequest, opts ...grpc.CallOption) (*proto.GetTTLResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.GetTTL(ctx, req)
+}
+func (c *TestClient) Touch(ctx context.Context, req *proto.TouchRequest, opts ...grpc.CallOption) (*proto.TouchResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.Touch(ctx, req)
+}
+func (c *TestClient) Persist(ctx context.Context, req *proto.PersistRequest, opts ...grpc.CallOption) (*proto.PersistResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.Persist(ctx, req)
+}
+func (c *TestClient) Increment(ctx context.Context, req *proto.IncrementRequest, opts ...grpc.CallOption) (*proto.IncrementResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.Increment(ctx, req)
+}
+func (c *TestClient) Decrement(ctx context.Context, req *proto.DecrementRequest, opts ...grpc.CallOption) (*proto.DecrementResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.Decrement(ctx, req)
+}
+func (c *TestClient) MultiGet(ctx context.Context, req *proto.MultiGetRequest, opts ...grpc.CallOption) (*proto.MultiGetResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.MultiGet(ctx, req)
+}
+func (c *TestClient) MultiSet(ctx context.Context, req *proto.MultiSetRequest, opts ...grpc.CallOption) (*proto.MultiSetResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.MultiSet(ctx, req)
+}
+func (c *TestClient) MultiDelete(ctx context.Context, req *proto.MultiDeleteRequest, opts ...grpc.CallOption) (*proto.MultiDeleteResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.MultiDelete(ctx, req)
+}
 func (c *TestClient) GetShardContents(ctx context.Context, req *proto.GetShardContentsRequest, opts ...grpc.CallOption) (*proto.GetShardContentsResponse, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -188,6 +403,171 @@ func (c *TestClient) GetShardContents(ctx context.Context, req *proto.GetShardCo
 	return c.server.GetShardContents(ctx, req)
 }
 
+func (c *TestClient) StreamShardContents(ctx context.Context, req *proto.StreamShardContentsRequest, opts ...grpc.CallOption) (proto.Kv_StreamShardContentsClient, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.disableStreaming {
+		return nil, status.Error(codes.Unimplemented, "method StreamShardContents not implemented")
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	if c.getShardContentsResponse != nil {
+		response := c.getShardContentsResponse
+		return startTestStream(ctx, func(stream *testStream[proto.GetShardContentsResponse]) error {
+			return stream.Send(response)
+		}), nil
+	}
+	server := c.server
+	return startTestStream(ctx, func(stream *testStream[proto.GetShardContentsResponse]) error {
+		return server.StreamShardContents(req, stream)
+	}), nil
+}
+
+func (c *TestClient) Scan(ctx context.Context, req *proto.ScanRequest, opts ...grpc.CallOption) (*proto.ScanResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.Scan(ctx, req)
+}
+
+func (c *TestClient) Watch(ctx context.Context, req *proto.WatchRequest, opts ...grpc.CallOption) (proto.Kv_WatchClient, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	server := c.server
+	return startTestStream(ctx, func(stream *testStream[proto.WatchEvent]) error {
+		return server.Watch(req, stream)
+	}), nil
+}
+
+func (c *TestClient) ReplicaWrite(ctx context.Context, req *proto.ReplicaWriteRequest, opts ...grpc.CallOption) (*proto.ReplicaWriteResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.ReplicaWrite(ctx, req)
+}
+
+func (c *TestClient) StoreHint(ctx context.Context, req *proto.StoreHintRequest, opts ...grpc.CallOption) (*proto.StoreHintResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.StoreHint(ctx, req)
+}
+
+func (c *TestClient) GetMerkleHashes(ctx context.Context, req *proto.MerkleHashesRequest, opts ...grpc.CallOption) (*proto.MerkleHashesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.GetMerkleHashes(ctx, req)
+}
+
+func (c *TestClient) GetMerkleLeaves(ctx context.Context, req *proto.MerkleLeavesRequest, opts ...grpc.CallOption) (*proto.MerkleLeavesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.GetMerkleLeaves(ctx, req)
+}
+
+func (c *TestClient) BackupWrite(ctx context.Context, req *proto.BackupWriteRequest, opts ...grpc.CallOption) (*proto.BackupWriteResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.BackupWrite(ctx, req)
+}
+
+func (c *TestClient) ChainWrite(ctx context.Context, req *proto.ChainWriteRequest, opts ...grpc.CallOption) (*proto.ChainWriteResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.ChainWrite(ctx, req)
+}
+
+func (c *TestClient) RaftRequestVote(ctx context.Context, req *proto.RaftRequestVoteRequest, opts ...grpc.CallOption) (*proto.RaftRequestVoteResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.RaftRequestVote(ctx, req)
+}
+
+func (c *TestClient) RaftAppendEntries(ctx context.Context, req *proto.RaftAppendEntriesRequest, opts ...grpc.CallOption) (*proto.RaftAppendEntriesResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.latencyInjection != nil {
+		time.Sleep(*c.latencyInjection)
+	}
+	return c.server.RaftAppendEntries(ctx, req)
+}
+
+func (c *TestClient) GetStats(ctx context.Context, req *proto.GetStatsRequest, opts ...grpc.CallOption) (*proto.GetStatsResponse, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	atomic.AddUint64(&c.requestsSent, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.server.GetStats(ctx, req)
+}
+
 func (c *TestClient) ClearOverrides() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -195,6 +575,7 @@ func (c *TestClient) ClearOverrides() {
 	c.getResponse = nil
 	c.getShardContentsResponse = nil
 	c.latencyInjection = nil
+	c.disableStreaming = false
 }
 
 func (c *TestClient) OverrideError(err error) {
@@ -229,8 +610,100 @@ func (c *TestClient) OverrideGetShardContentsResponse(response *proto.GetShardCo
 	c.getShardContentsResponse = response
 }
 
+// Makes streaming RPCs fail with Unimplemented, like a server from before they existed
+func (c *TestClient) DisableStreaming() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.disableStreaming = true
+}
+
 func (c *TestClient) SetLatencyInjection(duration time.Duration) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.latencyInjection = &duration
 }
+
+/*
+ * In-process replacement for a gRPC server-streaming call: the server handler runs
+ * on its own goroutine and each message it sends is handed to the client's Recv().
+ * testStream implements both the server and the client side of the stream.
+ */
+type testStream[T any] struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	messages chan *T
+	// set before messages and finished are closed
+	err error
+	// closed by SendHeader, and by the first Send like in gRPC
+	header     chan struct{}
+	headerOnce sync.Once
+	finished   chan struct{}
+}
+
+func startTestStream[T any](ctx context.Context, handler func(*testStream[T]) error) *testStream[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &testStream[T]{
+		ctx:      ctx,
+		cancel:   cancel,
+		messages: make(chan *T),
+		header:   make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	go func() {
+		stream.err = handler(stream)
+		close(stream.messages)
+		close(stream.finished)
+	}()
+	return stream
+}
+
+func (s *testStream[T]) Send(message *T) error {
+	s.SendHeader(nil)
+	select {
+	case s.messages <- message:
+		return nil
+	case <-s.ctx.Done():
+		return status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+func (s *testStream[T]) Recv() (*T, error) {
+	select {
+	case message, ok := <-s.messages:
+		if ok {
+			return message, nil
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+func (s *testStream[T]) Context() context.Context    { return s.ctx }
+func (s *testStream[T]) SetHeader(metadata.MD) error { return nil }
+func (s *testStream[T]) SetTrailer(metadata.MD)      {}
+
+func (s *testStream[T]) SendHeader(metadata.MD) error {
+	s.headerOnce.Do(func() { close(s.header) })
+	return nil
+}
+
+// Blocks until the handler sends headers or returns, like a gRPC client stream
+func (s *testStream[T]) Header() (metadata.MD, error) {
+	select {
+	case <-s.header:
+		return metadata.MD{}, nil
+	case <-s.finished:
+		return nil, s.err
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+func (s *testStream[T]) Trailer() metadata.MD        { return nil }
+func (s *testStream[T]) CloseSend() error            { return nil }
+func (s *testStream[T]) SendMsg(m interface{}) error { return s.Send(m.(*T)) }
+func (s *testStream[T]) RecvMsg(m interface{}) error { return errors.New("RecvMsg not supported") }
@@ -2,6 +2,7 @@ package kvtest
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -13,12 +14,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Storage engine of the servers created by the test setups. Unless it is set,
+// TestStorageEngines runs the suite again on each of the other engines.
+var testStorageEngine = flag.String("storage-engine", "", "Storage engine of the servers under test (default map)")
+
 type TestSetup struct {
-	shardMap   *kv.ShardMap
-	nodes      map[string]*kv.KvServerImpl
-	clientPool TestClientPool
-	kv         *kv.Kv
-	ctx        context.Context
+	shardMap    *kv.ShardMap
+	nodes       map[string]*kv.KvServerImpl
+	clientPool  TestClientPool
+	kv          *kv.Kv
+	ctx         context.Context
+	makeOptions func(nodeName string) kv.KvServerOptions
 }
 
 func MakeTestSetup(shardMap kv.ShardMapState) *TestSetup {
@@ -29,11 +35,16 @@ func MakeTestSetup(shardMap kv.ShardMapState) *TestSetup {
 	}
 	setup.shardMap.Update(&shardMap)
 	for name := range setup.shardMap.Nodes() {
-		setup.nodes[name] = kv.MakeKvServer(
+		server, err := kv.MakeKvServerWithOptions(
 			name,
 			setup.shardMap,
 			&setup.clientPool,
+			kv.KvServerOptions{StorageEngine: *testStorageEngine},
 		)
+		if err != nil {
+			panic(fmt.Sprintf("failed to create server %s: %v", name, err))
+		}
+		setup.nodes[name] = server
 	}
 	setup.clientPool.Setup(setup.nodes)
 	setup.kv = kv.MakeKv(setup.shardMap, &setup.clientPool)
@@ -44,6 +55,31 @@ func MakeTestSetup(shardMap kv.ShardMapState) *TestSetup {
 	return &setup
 }
 
+/*
+ * Like MakeTestSetup, but each server is created with the options returned by
+ * makeOptions(nodeName), e.g. to give every node its own data directory. Servers
+ * reach each other through clientPool.ForNode, so they can be partitioned.
+ */
+func MakeTestSetupWithOptions(shardMap kv.ShardMapState, makeOptions func(nodeName string) kv.KvServerOptions) *TestSetup {
+	setup := TestSetup{
+		shardMap:    &kv.ShardMap{},
+		ctx:         context.Background(),
+		nodes:       make(map[string]*kv.KvServerImpl),
+		makeOptions: makeOptions,
+	}
+	setup.shardMap.Update(&shardMap)
+	for name := range setup.shardMap.Nodes() {
+		server, err := kv.MakeKvServerWithOptions(name, setup.shardMap, setup.clientPool.ForNode(name), setup.nodeOptions(name))
+		if err != nil {
+			panic(fmt.Sprintf("failed to create server %s: %v", name, err))
+		}
+		setup.nodes[name] = server
+	}
+	setup.clientPool.Setup(setup.nodes)
+	setup.kv = kv.MakeKv(setup.shardMap, &setup.clientPool)
+	return &setup
+}
+
 func MakeTestSetupWithoutServers(shardMap kv.ShardMapState) *TestSetup {
 	// Remove nodes so we never have a chance of sending data
 	// to the KvServerImpl attached as a safety measure for client_test.go
@@ -78,6 +114,47 @@ func (ts *TestSetup) NodeGet(nodeName string, key string) (string, bool, error)
 	return response.Value, response.WasFound, nil
 }
 
+func (ts *TestSetup) NodeGetWithVersion(nodeName string, key string) (string, uint64, bool, error) {
+	response, err := ts.nodes[nodeName].Get(context.Background(), &proto.GetRequest{Key: key})
+	if err != nil || !response.WasFound {
+		return "", 0, false, err
+	}
+	return response.Value, response.Version, response.WasFound, nil
+}
+
+func (ts *TestSetup) NodeCompareAndSet(nodeName string, key string, value string, ttl time.Duration, expectedVersion uint64) (bool, uint64, error) {
+	response, err := ts.nodes[nodeName].CompareAndSet(
+		context.Background(),
+		&proto.CompareAndSetRequest{Key: key, Value: value, TtlMs: ttl.Milliseconds(), ExpectedVersion: expectedVersion},
+	)
+	if err != nil {
+		return false, 0, err
+	}
+	return response.Swapped, response.Version, nil
+}
+
+func (ts *TestSetup) NodeIncrement(nodeName string, key string, delta int64, ttl time.Duration) (int64, error) {
+	response, err := ts.nodes[nodeName].Increment(
+		context.Background(),
+		&proto.IncrementRequest{Key: key, Delta: delta, TtlMs: ttl.Milliseconds()},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return response.Value, nil
+}
+
+func (ts *TestSetup) NodeDecrement(nodeName string, key string, delta int64, ttl time.Duration) (int64, error) {
+	response, err := ts.nodes[nodeName].Decrement(
+		context.Background(),
+		&proto.DecrementRequest{Key: key, Delta: delta, TtlMs: ttl.Milliseconds()},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return response.Value, nil
+}
+
 func (ts *TestSetup) NodeSet(nodeName string, key string, value string, ttl time.Duration) error {
 	_, err := ts.nodes[nodeName].Set(
 		context.Background(),
@@ -91,6 +168,45 @@ func (ts *TestSetup) NodeDelete(nodeName string, key string) error {
 	return err
 }
 
+/*
+ * Shuts down a node and starts a fresh server with the same name (and options)
+ * in its place, simulating a process restart.
+ */
+func (ts *TestSetup) RestartNode(nodeName string) error {
+	ts.StopNode(nodeName)
+	return ts.StartNode(nodeName)
+}
+
+/*
+ * Shuts down a node without replacing it. The node must be started again with
+ * StartNode before any requests are sent to it (or before ts.Shutdown()).
+ */
+func (ts *TestSetup) StopNode(nodeName string) {
+	ts.nodes[nodeName].Shutdown()
+}
+
+// Options for (re)starting a node: makeOptions', if any, on testStorageEngine by default
+func (ts *TestSetup) nodeOptions(nodeName string) kv.KvServerOptions {
+	options := kv.KvServerOptions{}
+	if ts.makeOptions != nil {
+		options = ts.makeOptions(nodeName)
+	}
+	if options.StorageEngine == "" {
+		options.StorageEngine = *testStorageEngine
+	}
+	return options
+}
+
+func (ts *TestSetup) StartNode(nodeName string) error {
+	server, err := kv.MakeKvServerWithOptions(nodeName, ts.shardMap, ts.clientPool.ForNode(nodeName), ts.nodeOptions(nodeName))
+	if err != nil {
+		return err
+	}
+	ts.nodes[nodeName] = server
+	ts.clientPool.ReplaceServer(nodeName, server)
+	return nil
+}
+
 func (ts *TestSetup) Get(key string) (string, bool, error) {
 	return ts.kv.Get(ts.ctx, key)
 }
@@ -497,6 +613,16 @@ func MakeTwoNodeBothAssignedSingleShard() kv.ShardMapState {
 	}
 }
 
+func MakeThreeNodesAllAssignedSingleShard() kv.ShardMapState {
+	return kv.ShardMapState{
+		NumShards: 1,
+		Nodes:     makeNodeInfos(3),
+		ShardsToNodes: map[int][]string{
+			1: {"n1", "n2", "n3"},
+		},
+	}
+}
+
 func MakeTwoNodeMultiShard() kv.ShardMapState {
 	return kv.ShardMapState{
 		NumShards: 10,
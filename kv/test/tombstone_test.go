@@ -0,0 +1,134 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for the tombstones deletes leave behind.
+
+func nodeDeleteAt(t *testing.T, setup *TestSetup, node string, key string, timestamp uint64) {
+	_, err := setup.nodes[node].Delete(setup.ctx, &proto.DeleteRequest{Key: key, Timestamp: timestamp})
+	assert.Nil(t, err)
+}
+
+// Entries of shard 1 on `node`, tombstones included
+func shardContents(t *testing.T, setup *TestSetup, node string) map[string]*proto.GetShardValue {
+	response, err := setup.nodes[node].GetShardContents(setup.ctx, &proto.GetShardContentsRequest{Shard: 1})
+	assert.Nil(t, err)
+	contents := make(map[string]*proto.GetShardValue)
+	for _, value := range response.Values {
+		contents[string(value.Key)] = value
+	}
+	return contents
+}
+
+func TestTombstoneBlocksLateWrites(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	now := uint64(time.Now().UnixNano())
+	nodeSetAt(t, setup, "n1", "abc", "123", now)
+	nodeDeleteAt(t, setup, "n1", "abc", now+2)
+
+	// a Set sent before the delete arrives late
+	nodeSetAt(t, setup, "n1", "abc", "late", now+1)
+	response := nodeGetResponse(t, setup, "n1", "abc")
+	assert.False(t, response.WasFound)
+	assert.Equal(t, now+2, response.Timestamp)
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["stale_writes_ignored"])
+
+	// as does a delete older than a later Set
+	nodeSetAt(t, setup, "n1", "abc", "456", now+4)
+	nodeDeleteAt(t, setup, "n1", "abc", now+3)
+	response = nodeGetResponse(t, setup, "n1", "abc")
+	assert.True(t, response.WasFound)
+	assert.Equal(t, "456", response.Value)
+
+	// tombstones are invisible to clients otherwise
+	assert.Nil(t, setup.NodeDelete("n1", "abc"))
+	ttl, _, err := setup.kv.GetTTL(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), ttl)
+	swapped, version, err := setup.NodeCompareAndSet("n1", "abc", "new", 10*time.Second, 0)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	// versions keep increasing across deletes
+	assert.Equal(t, uint64(3), version)
+
+	setup.Shutdown()
+}
+
+func TestTombstoneWinsOverStaleReplica(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	// the delete only reaches n1
+	assert.Nil(t, setup.NodeDelete("n1", "abc"))
+
+	client := kv.MakeKvWithOptions(setup.shardMap, &setup.clientPool, kv.KvOptions{ReadConsistency: kv.ConsistencyAll})
+	_, wasFound, err := client.Get(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestTombstoneSurvivesShardMove(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards:     1,
+		Nodes:         makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{1: {"n1"}},
+	})
+	now := uint64(time.Now().UnixNano())
+	nodeSetAt(t, setup, "n1", "abc", "123", now)
+	nodeDeleteAt(t, setup, "n1", "abc", now+2)
+
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+	tombstone := shardContents(t, setup, "n2")["abc"]
+	assert.NotNil(t, tombstone)
+	assert.True(t, tombstone.Deleted)
+	assert.Equal(t, now+2, tombstone.Timestamp)
+
+	nodeSetAt(t, setup, "n2", "abc", "late", now+1)
+	_, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	setup.Shutdown()
+}
+
+func TestTombstoneSyncedByAntiEntropy(t *testing.T) {
+	setup := makeAntiEntropySetup(MakeTwoNodeBothAssignedSingleShard(), 0)
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	assert.Nil(t, setup.NodeDelete("n1", "abc"))
+
+	assert.Eventually(t, func() bool {
+		_, wasFound, err := setup.NodeGet("n2", "abc")
+		return err == nil && !wasFound
+	}, 2*time.Second, 20*time.Millisecond)
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+	assert.True(t, shardContents(t, setup, "n2")["abc"].Deleted)
+
+	setup.Shutdown()
+}
+
+func TestTombstoneCollectedAfterGracePeriod(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), func(nodeName string) kv.KvServerOptions {
+		return kv.KvServerOptions{TombstoneGracePeriod: 500 * time.Millisecond}
+	})
+	assert.Nil(t, setup.Set("abc", "123", 10*time.Second))
+	assert.Nil(t, setup.Delete("abc"))
+	assert.True(t, shardContents(t, setup, "n1")["abc"].Deleted)
+
+	assert.Eventually(t, func() bool {
+		return len(shardContents(t, setup, "n1")) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(1), setup.nodes["n1"].Stats()["tombstones_collected"])
+	assert.Equal(t, int64(0), setup.nodes["n1"].Stats()["memory_bytes"])
+
+	setup.Shutdown()
+}
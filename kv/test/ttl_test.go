@@ -0,0 +1,182 @@
+package kvtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for GetTTL, Touch and Persist.
+
+func nodeGetTTL(t *testing.T, setup *TestSetup, nodeName string, key string) *proto.GetTTLResponse {
+	response, err := setup.nodes[nodeName].GetTTL(context.Background(), &proto.GetTTLRequest{Key: key})
+	assert.Nil(t, err)
+	return response
+}
+
+func TestServerGetTTL(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+
+	assert.False(t, nodeGetTTL(t, setup, "n1", "abc").WasFound)
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 10*time.Second))
+	response := nodeGetTTL(t, setup, "n1", "abc")
+	assert.True(t, response.WasFound)
+	assert.False(t, response.NoExpiry)
+	assert.LessOrEqual(t, response.TtlMsRemaining, int64(10000))
+	assert.Greater(t, response.TtlMsRemaining, int64(9000))
+
+	_, err := setup.nodes["n1"].GetTTL(context.Background(), &proto.GetTTLRequest{Key: ""})
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+
+	setup.Shutdown()
+}
+
+func TestServerTouch(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	server := setup.nodes["n1"]
+
+	touch, err := server.Touch(context.Background(), &proto.TouchRequest{Key: "abc", TtlMs: 10000})
+	assert.Nil(t, err)
+	assert.False(t, touch.WasFound)
+	_, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	// extend
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 200*time.Millisecond))
+	touch, err = server.Touch(context.Background(), &proto.TouchRequest{Key: "abc", TtlMs: 10000})
+	assert.Nil(t, err)
+	assert.True(t, touch.WasFound)
+	time.Sleep(300 * time.Millisecond)
+	val, version, wasFound, err := setup.NodeGetWithVersion("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+	// the value was not rewritten
+	assert.Equal(t, uint64(1), version)
+
+	// shorten: the key must move up the TTL heap so the cleaner removes it
+	assert.Nil(t, setup.NodeSet("n1", "def", "456", 10*time.Second))
+	touch, err = server.Touch(context.Background(), &proto.TouchRequest{Key: "abc", TtlMs: 50})
+	assert.Nil(t, err)
+	assert.True(t, touch.WasFound)
+	assert.Eventually(t, func() bool {
+		return server.Stats()["memory_bytes"] == int64(len("def")+len("456"))
+	}, 5*time.Second, 50*time.Millisecond)
+	_, wasFound, err = setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	_, err = server.Touch(context.Background(), &proto.TouchRequest{Key: "def", TtlMs: -1})
+	assertErrorWithCode(t, err, codes.InvalidArgument)
+
+	setup.Shutdown()
+}
+
+func TestServerPersist(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	server := setup.nodes["n1"]
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 100*time.Millisecond))
+	persist, err := server.Persist(context.Background(), &proto.PersistRequest{Key: "abc"})
+	assert.Nil(t, err)
+	assert.True(t, persist.WasFound)
+
+	time.Sleep(200 * time.Millisecond)
+	val, wasFound, err := setup.NodeGet("n1", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+	response := nodeGetTTL(t, setup, "n1", "abc")
+	assert.True(t, response.WasFound)
+	assert.True(t, response.NoExpiry)
+
+	// still visible to scans and counters
+	scan, err := server.Scan(context.Background(), &proto.ScanRequest{Shard: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(scan.Values))
+	assert.True(t, scan.Values[0].NoExpiry)
+	assert.Nil(t, setup.NodeSet("n1", "abc", "1", 100*time.Millisecond))
+	_, err = server.Persist(context.Background(), &proto.PersistRequest{Key: "abc"})
+	assert.Nil(t, err)
+	_, err = setup.NodeIncrement("n1", "abc", 1, 100*time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, nodeGetTTL(t, setup, "n1", "abc").NoExpiry)
+
+	// a Set gives the key a TTL again
+	assert.Nil(t, setup.NodeSet("n1", "abc", "456", 10*time.Second))
+	assert.False(t, nodeGetTTL(t, setup, "n1", "abc").NoExpiry)
+
+	// expired keys are not revived
+	assert.Nil(t, setup.NodeSet("n1", "def", "456", 50*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	persist, err = server.Persist(context.Background(), &proto.PersistRequest{Key: "def"})
+	assert.Nil(t, err)
+	assert.False(t, persist.WasFound)
+
+	setup.Shutdown()
+}
+
+func TestPersistedKeySurvivesShardMoveAndRestart(t *testing.T) {
+	setup := MakeTestSetupWithOptions(kv.ShardMapState{
+		NumShards: 1,
+		Nodes:     makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{
+			1: {"n1"},
+		},
+	}, makeDataDirOptions(t, time.Minute))
+
+	assert.Nil(t, setup.NodeSet("n1", "abc", "123", 100*time.Millisecond))
+	_, err := setup.nodes["n1"].Persist(context.Background(), &proto.PersistRequest{Key: "abc"})
+	assert.Nil(t, err)
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+	assert.True(t, nodeGetTTL(t, setup, "n2", "abc").NoExpiry)
+
+	assert.Nil(t, setup.RestartNode("n2"))
+	time.Sleep(200 * time.Millisecond)
+	val, wasFound, err := setup.NodeGet("n2", "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Equal(t, "123", val)
+	assert.True(t, nodeGetTTL(t, setup, "n2", "abc").NoExpiry)
+
+	setup.Shutdown()
+}
+
+func TestClientTouchPersistReplicated(t *testing.T) {
+	setup := MakeTestSetup(MakeTwoNodeBothAssignedSingleShard())
+
+	wasFound, err := setup.kv.Touch(setup.ctx, "abc", 10*time.Second)
+	assert.Nil(t, err)
+	assert.False(t, wasFound)
+
+	assert.Nil(t, setup.Set("abc", "123", 1*time.Second))
+	wasFound, err = setup.kv.Touch(setup.ctx, "abc", 20*time.Second)
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	for _, node := range []string{"n1", "n2"} {
+		assert.Greater(t, nodeGetTTL(t, setup, node, "abc").TtlMsRemaining, int64(19000))
+	}
+	ttl, wasFound, err := setup.kv.GetTTL(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	assert.Greater(t, ttl, 19*time.Second)
+
+	wasFound, err = setup.kv.Persist(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.True(t, wasFound)
+	for _, node := range []string{"n1", "n2"} {
+		assert.True(t, nodeGetTTL(t, setup, node, "abc").NoExpiry)
+	}
+	ttl, _, err = setup.kv.GetTTL(setup.ctx, "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, kv.NoExpiry, ttl)
+
+	setup.Shutdown()
+}
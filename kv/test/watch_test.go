@@ -0,0 +1,187 @@
+package kvtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cs426.yale.edu/lab4/kv"
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// Tests for Watch (server) and Kv.Watch (client).
+
+func nextWatchEvent(t *testing.T, events <-chan kv.WatchEvent, timeout time.Duration) kv.WatchEvent {
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok, "watch channel closed")
+		return event
+	case <-time.After(timeout):
+		assert.Fail(t, "timed out waiting for watch event")
+		return kv.WatchEvent{}
+	}
+}
+
+func assertNoWatchEvent(t *testing.T, events <-chan kv.WatchEvent) {
+	select {
+	case event := <-events:
+		assert.Fail(t, "unexpected watch event", "%+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestServerWatchErrors(t *testing.T) {
+	setup := MakeTestSetup(MakeSingleNodeHalfShardsAssigned())
+
+	watch := func(request *proto.WatchRequest) error {
+		stream := startTestStream(context.Background(), func(stream *testStream[proto.WatchEvent]) error {
+			return setup.nodes["n1"].Watch(request, stream)
+		})
+		defer stream.cancel()
+		_, err := stream.Header()
+		return err
+	}
+	// "abc" is on shard 4, "xyz" on shard 7 which is not hosted
+	assert.Nil(t, watch(&proto.WatchRequest{Shard: 4, Key: []byte("abc")}))
+	assertErrorWithCode(t, watch(&proto.WatchRequest{Shard: 7, Key: []byte("xyz")}), codes.NotFound)
+	assertErrorWithCode(t, watch(&proto.WatchRequest{Shard: 3, Key: []byte("abc")}), codes.InvalidArgument)
+	assertErrorWithCode(t, watch(&proto.WatchRequest{Shard: 4}), codes.InvalidArgument)
+	assert.Nil(t, watch(&proto.WatchRequest{Shard: 4, Prefix: true}))
+
+	setup.Shutdown()
+}
+
+func TestClientWatchKey(t *testing.T) {
+	setup := MakeTestSetup(MakeBasicOneShard())
+	ctx, cancel := context.WithCancel(setup.ctx)
+
+	events, err := setup.kv.Watch(ctx, "abc", false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, setup.Set("abc", "hello", 10*time.Second))
+	assert.Nil(t, setup.Set("abcd", "other key", 10*time.Second))
+	_, err = setup.kv.Increment(setup.ctx, "abc", 1, 10*time.Second)
+	assertErrorWithCode(t, err, codes.FailedPrecondition)
+	assert.Nil(t, setup.Delete("abc"))
+
+	event := nextWatchEvent(t, events, time.Second)
+	assert.Equal(t, kv.WatchEvent{Type: kv.WatchSet, Key: "abc", Value: "hello", Version: 1}, event)
+	event = nextWatchEvent(t, events, time.Second)
+	assert.Equal(t, kv.WatchEvent{Type: kv.WatchDelete, Key: "abc"}, event)
+	assertNoWatchEvent(t, events)
+
+	// expirations are reported by the cleanup loop
+	assert.Nil(t, setup.Set("abc", "456", 100*time.Millisecond))
+	event = nextWatchEvent(t, events, time.Second)
+	assert.Equal(t, kv.WatchSet, event.Type)
+	event = nextWatchEvent(t, events, 5*time.Second)
+	assert.Equal(t, kv.WatchEvent{Type: kv.WatchExpire, Key: "abc"}, event)
+
+	cancel()
+	for range events {
+	}
+
+	setup.Shutdown()
+}
+
+func TestClientWatchPrefixAcrossShards(t *testing.T) {
+	setup := MakeTestSetup(MakeFourNodesWithFiveShards())
+	ctx, cancel := context.WithCancel(setup.ctx)
+
+	events, err := setup.kv.Watch(ctx, "user:", true)
+	assert.Nil(t, err)
+
+	keys := RandomKeys(100, 10)
+	for _, key := range keys {
+		assert.Nil(t, setup.Set("user:"+key, key, 10*time.Second))
+		assert.Nil(t, setup.Set("other:"+key, key, 10*time.Second))
+	}
+
+	// each shard is watched on a single replica, so every write is seen once
+	seen := make(map[string]int)
+	for i := 0; i < len(keys); i++ {
+		event := nextWatchEvent(t, events, time.Second)
+		assert.Equal(t, kv.WatchSet, event.Type)
+		assert.Equal(t, "user:"+event.Value, event.Key)
+		seen[event.Key]++
+	}
+	assertNoWatchEvent(t, events)
+	for _, key := range keys {
+		assert.Equal(t, 1, seen["user:"+key])
+	}
+
+	cancel()
+	for range events {
+	}
+	// the listener is gone, so ShardMap updates do not block on it
+	setup.UpdateShardMapping(setup.getShardMapStateCopy().ShardsToNodes)
+
+	setup.Shutdown()
+}
+
+func TestClientWatchEviction(t *testing.T) {
+	setup := MakeTestSetupWithOptions(MakeBasicOneShard(), makeBudgetOptions(200, kv.EvictLRU))
+
+	events, err := setup.kv.Watch(setup.ctx, "", true)
+	assert.Nil(t, err)
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, setup.Set(key, valueOfSize(key, 100), 10*time.Second))
+	}
+	types := make([]kv.WatchEventType, 0)
+	keys := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		event := nextWatchEvent(t, events, time.Second)
+		types = append(types, event.Type)
+		keys = append(keys, event.Key)
+	}
+	// the write is applied (and reported) before making room for it
+	assert.Equal(t, []kv.WatchEventType{kv.WatchSet, kv.WatchSet, kv.WatchSet, kv.WatchEvict}, types)
+	assert.Equal(t, []string{"a", "b", "c", "a"}, keys)
+
+	setup.Shutdown()
+}
+
+func TestClientWatchResubscribesWhenShardMoves(t *testing.T) {
+	setup := MakeTestSetup(kv.ShardMapState{
+		NumShards: 1,
+		Nodes:     makeNodeInfos(2),
+		ShardsToNodes: map[int][]string{
+			1: {"n1"},
+		},
+	})
+	ctx, cancel := context.WithCancel(setup.ctx)
+
+	events, err := setup.kv.Watch(ctx, "abc", false)
+	assert.Nil(t, err)
+
+	assert.Nil(t, setup.MoveShard(1, "n1", "n2"))
+	event := nextWatchEvent(t, events, 5*time.Second)
+	assert.Equal(t, kv.WatchResync, event.Type)
+	// MoveShard updates the ShardMap twice, so there may be more resyncs
+	for settled := false; !settled; {
+		select {
+		case event = <-events:
+			assert.Equal(t, kv.WatchResync, event.Type)
+		case <-time.After(200 * time.Millisecond):
+			settled = true
+		}
+	}
+
+	// n1 no longer has the shard, so the subscription must be on n2 now
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, setup.Set("abc", fmt.Sprintf("v%d", i), 10*time.Second))
+		event = nextWatchEvent(t, events, time.Second)
+		assert.Equal(t, kv.WatchSet, event.Type)
+		assert.Equal(t, fmt.Sprintf("v%d", i), event.Value)
+	}
+
+	cancel()
+	for range events {
+	}
+
+	setup.Shutdown()
+}
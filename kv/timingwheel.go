@@ -0,0 +1,217 @@
+package kv
+
+/*
+ * Hierarchical timing wheel tracking when the entries of one shard expire.
+ *
+ * Time is cut into ticks of tickMs. Level 0 has one slot per tick for the next
+ * wheelSlots ticks, and each slot of level L spans wheelSlots^L ticks, so a few
+ * levels reach years ahead. An entry is linked into the lowest level which reaches
+ * its expiry, and is moved down a level (cascaded) once time gets close enough.
+ * Adding and removing entries is O(1), and advance only visits the slots which
+ * came due since the last call, instead of the O(log n) work per write and per
+ * expiry of a heap.
+ *
+ * Entries are linked into their slot through their wheel* fields. Entries which
+ * never expire are kept on a list of their own, so the ttl eviction policy can
+ * still pick them once nothing else is left. The slots are only allocated while
+ * entries are scheduled, since most shards of a server are not hosted by it.
+ *
+ * NOTE: not safe for concurrent use; the server guards each wheel with the lock
+ * of its shard.
+ */
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 5
+)
+
+// Doubly-linked list of the entries in one slot
+type wheelSlot struct {
+	head *entry
+}
+
+func (s *wheelSlot) push(e *entry) {
+	e.wheelSlot = s
+	e.wheelPrev = nil
+	e.wheelNext = s.head
+	if s.head != nil {
+		s.head.wheelPrev = e
+	}
+	s.head = e
+}
+
+func (s *wheelSlot) unlink(e *entry) {
+	if e.wheelPrev != nil {
+		e.wheelPrev.wheelNext = e.wheelNext
+	} else {
+		s.head = e.wheelNext
+	}
+	if e.wheelNext != nil {
+		e.wheelNext.wheelPrev = e.wheelPrev
+	}
+	e.wheelSlot, e.wheelPrev, e.wheelNext = nil, nil, nil
+}
+
+type timingWheel struct {
+	tickMs uint64
+	// Next tick to expire: everything due before it has been returned by advance.
+	// For levels above 0, the slot `current` falls in has already been cascaded,
+	// so it only holds entries for its next turn around the wheel.
+	current uint64
+	// nil while no entries are scheduled
+	slots *[wheelLevels][wheelSlots]wheelSlot
+	// number of entries in slots
+	scheduled int
+	// entries with ttl == noExpiry
+	never wheelSlot
+}
+
+func newTimingWheel(tickMs uint64, now uint64) *timingWheel {
+	return &timingWheel{tickMs: tickMs, current: now / tickMs}
+}
+
+// Drops every entry, e.g. when the shard is cleared
+func (w *timingWheel) reset(now uint64) {
+	*w = timingWheel{tickMs: w.tickMs, current: now / w.tickMs}
+}
+
+func (w *timingWheel) add(e *entry) {
+	if e.ttl == noExpiry {
+		w.never.push(e)
+		return
+	}
+	if w.slots == nil {
+		w.slots = new([wheelLevels][wheelSlots]wheelSlot)
+	}
+	w.slotFor(e.ttl / w.tickMs).push(e)
+	w.scheduled++
+}
+
+// No-op if the entry is not in the wheel (e.g. it was just returned by advance)
+func (w *timingWheel) remove(e *entry) {
+	if e.wheelSlot == nil {
+		return
+	}
+	if e.wheelSlot != &w.never {
+		w.scheduled--
+	}
+	e.wheelSlot.unlink(e)
+	if w.scheduled == 0 {
+		w.slots = nil
+	}
+}
+
+func (w *timingWheel) slotFor(tick uint64) *wheelSlot {
+	if tick < w.current {
+		// already due: expire it on the next advance
+		tick = w.current
+	}
+	delta := tick - w.current
+	for level := 0; level < wheelLevels; level++ {
+		if delta < 1<<(wheelBits*(level+1)) {
+			return &w.slots[level][(tick>>(wheelBits*level))&wheelMask]
+		}
+	}
+	// Further out than the wheel reaches: park it in the top-level slot which is
+	// cascaded last, which places it again.
+	top := wheelLevels - 1
+	return &w.slots[top][(w.current>>(wheelBits*top))&wheelMask]
+}
+
+/*
+ * Unlinks and returns every entry which expired before `now` (in ms). Entries are
+ * only returned once their whole tick has passed, so they are always past their
+ * TTL, but may be returned up to one tick after it.
+ */
+func (w *timingWheel) advance(now uint64) []*entry {
+	var expired []*entry
+	target := now / w.tickMs
+	if w.scheduled == 0 {
+		w.current = max(w.current, target)
+		return nil
+	}
+	for w.current < target && w.scheduled > 0 {
+		slot := &w.slots[0][w.current&wheelMask]
+		for slot.head != nil {
+			e := slot.head
+			slot.unlink(e)
+			expired = append(expired, e)
+			w.scheduled--
+		}
+		w.current++
+		if w.current&wheelMask == 0 {
+			// level 0 wrapped around: bring down the entries of the next slot of
+			// level 1, and so on up while levels wrap around too
+			for level := 1; level < wheelLevels; level++ {
+				index := (w.current >> (wheelBits * level)) & wheelMask
+				w.cascade(&w.slots[level][index])
+				if index != 0 {
+					break
+				}
+			}
+		}
+	}
+	if w.scheduled == 0 {
+		w.slots = nil
+		w.current = max(w.current, target)
+	}
+	return expired
+}
+
+func (w *timingWheel) cascade(slot *wheelSlot) {
+	e := slot.head
+	slot.head = nil
+	for e != nil {
+		next := e.wheelNext
+		w.slotFor(e.ttl / w.tickMs).push(e)
+		e = next
+	}
+}
+
+/*
+ * The entry which expires first (entries which never expire come last), or nil
+ * if the wheel is empty. Tombstones are skipped, since they are never evicted
+ * (see putEntry). Within each level, the first slot in time order holding a
+ * value holds that level's earliest values, so this looks at one such slot per
+ * level.
+ *
+ * O(wheelSlots * wheelLevels) plus the size of the slots examined: meant for
+ * eviction, not for the write path.
+ */
+func (w *timingWheel) soonest() *entry {
+	var best *entry
+	for level := 0; level < wheelLevels && w.slots != nil; level++ {
+		start := w.current >> (wheelBits * level)
+		first := uint64(1)
+		if level == 0 {
+			// the current level 0 slot holds the entries due right now
+			first = 0
+		}
+		for i := first; i < first+wheelSlots; i++ {
+			found := false
+			for e := w.slots[level][(start+i)&wheelMask].head; e != nil; e = e.wheelNext {
+				if e.deleted {
+					continue
+				}
+				found = true
+				if best == nil || e.ttl < best.ttl {
+					best = e
+				}
+			}
+			if found {
+				break
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	for e := w.never.head; e != nil; e = e.wheelNext {
+		if !e.deleted {
+			return e
+		}
+	}
+	return nil
+}
@@ -13,40 +13,11 @@ func GetShardForKey(key string, numShards int) int {
 	return int(hasher.Sum32())%numShards + 1
 }
 
-type EntryHeap []*entry
-
-func (h *EntryHeap) Len() int {
-	return len(*h)
-}
-
-func (h *EntryHeap) Less(i, j int) bool {
-	return (*h)[i].ttl < (*h)[j].ttl
-}
-
-func (h *EntryHeap) Swap(i, j int) {
-	(*h)[i], (*h)[j] = (*h)[j], (*h)[i]
-	// Update the index fields
-	(*h)[i].index = i
-	(*h)[j].index = j
-}
-
-func (h *EntryHeap) Push(x interface{}) {
-	entry := x.(*entry)
-	// Append the new entry and set its index
-	entry.index = len(*h)
-	*h = append(*h, entry)
-}
-
-func (h *EntryHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	// Get the last entry
-	entry := old[n-1]
-	// Remove reference to avoid memory leak
-	old[n-1] = nil
-	// Update the heap slice
-	*h = old[0 : n-1]
-	// Invalidate the index
-	entry.index = -1
-	return entry
+// Whether a value written at timestamp1 with version1 is newer than the other.
+// Keys which were not found have a timestamp of 0, so any value is newer.
+func newerValue(timestamp1 uint64, version1 uint64, timestamp2 uint64, version2 uint64) bool {
+	if timestamp1 != timestamp2 {
+		return timestamp1 > timestamp2
+	}
+	return version1 > version2
 }
@@ -0,0 +1,401 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"cs426.yale.edu/lab4/kv/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Events buffered per subscriber (server side) and per Kv.Watch call (client side).
+// A server-side subscriber which falls further behind than this is disconnected.
+const watchBufferSize = 1024
+
+/*
+ * One active Watch stream on the server.
+ */
+type watcher struct {
+	shard  int
+	key    string
+	prefix bool
+	events chan *proto.WatchEvent
+	// closed (after setting err) when the server ends the watch
+	done chan struct{}
+	err  error
+}
+
+func (w *watcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+	return key == w.key
+}
+
+/*
+ * Tracks the active watchers of a server and fans events out to them. Events are
+ * published while holding the shard lock, so watchers of a key see its changes in
+ * order; publishing never blocks.
+ */
+type watchHub struct {
+	mutex    sync.RWMutex
+	watchers map[*watcher]struct{}
+}
+
+func (h *watchHub) subscribe(shard int, key string, prefix bool) *watcher {
+	w := &watcher{
+		shard:  shard,
+		key:    key,
+		prefix: prefix,
+		events: make(chan *proto.WatchEvent, watchBufferSize),
+		done:   make(chan struct{}),
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.watchers == nil {
+		h.watchers = make(map[*watcher]struct{})
+	}
+	h.watchers[w] = struct{}{}
+	return w
+}
+
+func (h *watchHub) unsubscribe(w *watcher) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.watchers, w)
+}
+
+/*
+ * Value and version are only sent for SET events. The event is only built if
+ * someone is watching the key, so writes cost little when there are no watchers.
+ *
+ * NOTE: must hold the lock for `shard`
+ */
+func (h *watchHub) publish(shard int, eventType proto.WatchEvent_Type, key string, value string, version uint64) {
+	var event *proto.WatchEvent
+	var overflowed []*watcher
+	h.mutex.RLock()
+	for w := range h.watchers {
+		if w.shard != shard || !w.matches(key) {
+			continue
+		}
+		if event == nil {
+			event = &proto.WatchEvent{Type: eventType, Key: []byte(key)}
+			if eventType == proto.WatchEvent_SET {
+				event.Value = []byte(value)
+				event.Version = version
+			}
+		}
+		select {
+		case w.events <- event:
+		default:
+			overflowed = append(overflowed, w)
+		}
+	}
+	h.mutex.RUnlock()
+
+	for _, w := range overflowed {
+		h.end(w, status.Error(codes.ResourceExhausted, "watcher fell too far behind"))
+	}
+}
+
+// Ends every watch on `shard`, e.g. because it is no longer hosted here
+func (h *watchHub) closeShard(shard int, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for w := range h.watchers {
+		if w.shard == shard {
+			h.endLocked(w, err)
+		}
+	}
+}
+
+func (h *watchHub) closeAll(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for w := range h.watchers {
+		h.endLocked(w, err)
+	}
+}
+
+func (h *watchHub) end(w *watcher, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.endLocked(w, err)
+}
+
+func (h *watchHub) endLocked(w *watcher, err error) {
+	if _, ok := h.watchers[w]; !ok {
+		// already ended
+		return
+	}
+	delete(h.watchers, w)
+	w.err = err
+	close(w.done)
+}
+
+/*
+ * Streams changes to the watched key(s) in a shard hosted by this node. SET events
+ * come from Set, CompareAndSet, Increment/Decrement and their batched/bytes forms,
+ * DELETE from Delete, EXPIRE from the periodic cleanup (so up to one cleanup
+ * interval after the key stopped being readable) and EVICT from memory-budget
+ * eviction. Changes to a key's TTL alone (Touch/Persist) and shard copies do not
+ * produce events.
+ */
+func (server *KvServerImpl) Watch(
+	request *proto.WatchRequest,
+	stream proto.Kv_WatchServer,
+) error {
+	shard := int(request.Shard)
+	key := string(request.Key)
+	if !request.Prefix {
+		if key == "" {
+			return status.Error(codes.InvalidArgument, "Empty key not allowed")
+		}
+		if GetShardForKey(key, server.shardMap.NumShards()) != shard {
+			return status.Error(codes.InvalidArgument, "Key does not belong to the shard")
+		}
+	}
+	if !server.isShardHosted(shard) {
+		return status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+
+	w := server.watches.subscribe(shard, key, request.Prefix)
+	defer server.watches.unsubscribe(w)
+	// the shard may have been dropped before we subscribed, in which case
+	// closeShard missed us
+	if !server.isShardHosted(shard) {
+		return status.Error(codes.NotFound, "Shard not hosted on this server")
+	}
+	// lets the client know it will not miss any change from now on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event := <-w.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-w.done:
+			return w.err
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+type WatchEventType int
+
+const (
+	WatchSet WatchEventType = iota
+	WatchDelete
+	WatchExpire
+	WatchEvict
+	// The subscription for a shard was re-established (after an error, or because
+	// the shard moved), so changes to keys in that shard may have been missed.
+	// Key is empty: callers caching values should treat every watched key as stale.
+	WatchResync
+)
+
+type WatchEvent struct {
+	Type WatchEventType
+	Key  string
+	// for WatchSet only
+	Value   string
+	Version uint64
+}
+
+/*
+ * Subscribes to changes to `key`, or with `prefix` set, to every key starting
+ * with `key`. Events are delivered on the returned channel, which is closed once
+ * ctx is cancelled; the caller must keep draining it until then.
+ *
+ * Each shard involved (just one for a single key, all of them for a prefix) is
+ * watched on one of its replicas. Since every replica applies every write, one is
+ * enough to see all changes. If that replica fails, or the ShardMap changes, the
+ * shard is re-subscribed (on a replica picked round-robin) and a WatchResync event
+ * is delivered.
+ *
+ * Returns once every shard has an active subscription, so changes made after
+ * Watch returns are guaranteed to be reported. Fails if a shard which has replicas
+ * cannot be subscribed to on any of them.
+ */
+func (kv *Kv) Watch(ctx context.Context, key string, prefix bool) (<-chan WatchEvent, error) {
+	numShards := kv.shardMap.NumShards()
+	shards := []int{GetShardForKey(key, numShards)}
+	if prefix {
+		shards = make([]int, 0, numShards)
+		for shard := 1; shard <= numShards; shard++ {
+			shards = append(shards, shard)
+		}
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	request := &proto.WatchRequest{Key: []byte(key), Prefix: prefix}
+	subscriptions := make([]*shardSubscription, len(shards))
+	for i, shard := range shards {
+		subscriptions[i] = &shardSubscription{kv: kv, shard: shard, request: request}
+		err := subscriptions[i].subscribe(watchCtx)
+		if err != nil && len(kv.shardMap.NodesForShard(shard)) > 0 {
+			cancel()
+			return nil, err
+		}
+	}
+
+	events := make(chan WatchEvent, watchBufferSize)
+	listener := kv.shardMap.MakeListener()
+	var wg sync.WaitGroup
+	for _, subscription := range subscriptions {
+		wg.Add(1)
+		go func(subscription *shardSubscription) {
+			defer wg.Done()
+			subscription.run(watchCtx, events)
+		}(subscription)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-listener.UpdateChannel():
+				// replicas may have changed: move every subscription to a
+				// current replica (run() re-subscribes once its stream ends)
+				for _, subscription := range subscriptions {
+					subscription.restart()
+				}
+			case <-watchCtx.Done():
+				// Update() blocks until we receive, so keep draining until
+				// Close() has removed the listener
+				go listener.Close()
+				for range listener.UpdateChannel() {
+				}
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		cancel()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+/*
+ * Subscription for one shard of a Kv.Watch call.
+ */
+type shardSubscription struct {
+	kv      *Kv
+	shard   int
+	request *proto.WatchRequest
+
+	mutex  sync.Mutex
+	stream proto.Kv_WatchClient
+	// cancels stream
+	cancel context.CancelFunc
+}
+
+/*
+ * Opens a stream on one of the shard's replicas and waits for it to be active.
+ */
+func (s *shardSubscription) subscribe(ctx context.Context) error {
+	nodes := s.kv.replicaOrder(s.shard)
+	if len(nodes) == 0 {
+		return errors.New("no nodes available for shard")
+	}
+
+	request := &proto.WatchRequest{Shard: int32(s.shard), Key: s.request.Key, Prefix: s.request.Prefix}
+	var lastErr error
+	for _, node := range nodes {
+		client, err := s.kv.clientPool.GetClient(node)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream, err := client.Watch(streamCtx, request)
+		if err == nil {
+			_, err = stream.Header()
+		}
+		if err != nil {
+			cancel()
+			lastErr = err
+			continue
+		}
+
+		s.mutex.Lock()
+		s.stream, s.cancel = stream, cancel
+		s.mutex.Unlock()
+		return nil
+	}
+	return lastErr
+}
+
+func (s *shardSubscription) restart() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+/*
+ * Forwards events from the current stream until it ends, then re-subscribes with
+ * backoff, until ctx is cancelled.
+ */
+func (s *shardSubscription) run(ctx context.Context, events chan<- WatchEvent) {
+	for {
+		s.mutex.Lock()
+		stream := s.stream
+		s.mutex.Unlock()
+
+		for stream != nil {
+			event, err := stream.Recv()
+			if err != nil {
+				logrus.WithField("shard", s.shard).Debugf("watch stream ended: %q", err)
+				break
+			}
+			forwarded := WatchEvent{Type: WatchEventType(event.Type), Key: string(event.Key)}
+			if event.Type == proto.WatchEvent_SET {
+				forwarded.Value = string(event.Value)
+				forwarded.Version = event.Version
+			}
+			select {
+			case events <- forwarded:
+			case <-ctx.Done():
+				return
+			}
+		}
+		s.restart()
+
+		backoff := 50 * time.Millisecond
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			if s.subscribe(ctx) == nil {
+				break
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			backoff = min(2*backoff, 2*time.Second)
+		}
+		select {
+		case events <- WatchEvent{Type: WatchResync}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}